@@ -4,13 +4,27 @@ import (
 	_ "ai-service/docs"
 	"ai-service/internal/repository"
 	"ai-service/internal/repository/postgres"
+	"ai-service/internal/service/admin"
 	"ai-service/internal/service/auth"
+	"ai-service/internal/service/cache"
 	"ai-service/internal/service/chat"
 	"ai-service/internal/service/doc"
+	"ai-service/internal/service/feedback"
+	"ai-service/internal/service/grounding"
+	"ai-service/internal/service/guardrails"
+	"ai-service/internal/service/ingest"
+	"ai-service/internal/service/ocr"
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/service/openai"
+	"ai-service/internal/service/redaction"
+	"ai-service/internal/service/tools"
 	"ai-service/internal/util/config"
 	authMiddleware "ai-service/internal/util/middleware"
 	"ai-service/internal/util/validator"
 	"context"
+	"log"
+	"time"
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	echoSwagger "github.com/swaggo/echo-swagger"
@@ -40,15 +54,40 @@ func (r Router) Build(ctx context.Context) *echo.Echo {
 	userRepo := postgres.NewUserRepository(db)
 	jwtSecret := []byte(r.config.JWTSecret)
 
+	llmService, err := ollama.NewLLMService(ctx, r.config, r.repository)
+	if err != nil {
+		panic(err)
+	}
+	redactor, err := redaction.NewRedactor(r.config)
+	if err != nil {
+		panic(err)
+	}
+	llmService = redaction.Wrap(llmService)
+
 	docRepo := postgres.NewDocumentRepository(db)
-	docService, err := doc.NewDocService(r.config, r.repository, docRepo)
+	requestRepo := postgres.NewIdempotencyRepository(db, r.config.Idempotency.TTL)
+	go purgeExpiredRequests(ctx, requestRepo)
+	answerCache := cache.NewAnswerCache(r.config.AnswerCache)
+	ocrBackend, err := ocr.New(r.config.OCR, llmService)
+	if err != nil {
+		panic(err)
+	}
+	jobRepo := postgres.NewJobRepository(db)
+	ingester := ingest.NewIngester(r.config, r.repository, llmService, docRepo, jobRepo, redactor, ocrBackend)
+	go ingester.Run(ctx)
+	docService, err := doc.NewDocService(r.config, r.repository, llmService, docRepo, requestRepo, answerCache, jobRepo, ingester)
 	if err != nil {
 		panic(err)
 	}
 
-	svc := auth.NewService(userRepo, jwtSecret)
+	svc := auth.NewService(userRepo, postgres.NewAPIKeyRepository(db), jwtSecret)
 	authHandler := auth.NewAuthHandler(svc)
-	authMw := authMiddleware.AuthMiddleware(svc.ParseAccessToken)
+	authMw := authMiddleware.AuthMiddleware(svc.Authenticate)
+	// Keys are managed with an access token only, so a leaked key cannot
+	// issue new ones.
+	sessionMw := authMiddleware.AuthMiddleware(func(_ context.Context, token string) (string, error) {
+		return svc.ParseAccessToken(token)
+	})
 
 	{
 		e.GET("/swagger/*", echoSwagger.WrapHandler)
@@ -57,21 +96,86 @@ func (r Router) Build(ctx context.Context) *echo.Echo {
 		e.POST("/logout", authHandler.Logout)
 		e.POST("/register", authHandler.Register)
 	}
-	api := e.Group("", authMw)
+	{
+		services := e.Group("/keys", sessionMw)
+		services.POST("", authHandler.CreateAPIKey)
+		services.GET("", authHandler.ListAPIKeys)
+		services.DELETE("/:id", authHandler.RevokeAPIKey)
+	}
+	api := e.Group("", authMw, redaction.Middleware(redactor))
 	{
 		services := api.Group("/upload")
 		services.POST("", docService.SaveDoc)
+		services.POST("/files", docService.UploadFiles)
 		services.GET("", docService.ListDoc)
+		services.GET("/:id", docService.GetStatus)
 		services.PUT("/:id", docService.UpdatePriority)
 		services.DELETE("/:id", docService.DeleteDoc)
 	}
-	chatService, err := chat.NewChatService(r.config, r.repository)
+	guard, err := guardrails.NewGuard(r.config.Guardrails, llmService)
 	if err != nil {
 		panic(err)
 	}
+	toolRegistry := tools.NewRegistry(
+		tools.NewSearchDocuments(llmService, r.repository, guard),
+		tools.NewListDocuments(docRepo),
+		tools.NewGetDocumentChunk(r.repository, guard),
+	)
+	exchangeRepo := postgres.NewExchangeRepository(db)
+	chatService, err := chat.NewChatService(r.config, r.repository, llmService, exchangeRepo, requestRepo, toolRegistry, answerCache, grounding.NewChecker(r.config.Grounding, llmService), guard)
+	if err != nil {
+		panic(err)
+	}
+	feedbackService := feedback.NewFeedbackService(exchangeRepo)
 	{
 		services := api.Group("/chat")
 		services.POST("", chatService.Chat)
+		services.POST("/feedback", feedbackService.Rate)
+	}
+	openAIService := openai.NewOpenAIService(r.config, llmService, guard)
+	{
+		services := api.Group("/v1")
+		services.GET("/models", openAIService.Models)
+		services.POST("/chat/completions", openAIService.ChatCompletions)
+		services.POST("/embeddings", openAIService.Embeddings)
+	}
+	adminGroup := api.Group("/admin", authMiddleware.AdminMiddleware(r.config.Admins))
+	{
+		services := adminGroup.Group("/feedback")
+		services.GET("", feedbackService.ListNegative)
+		services.GET("/export", feedbackService.ExportNegative)
+	}
+	adminService := admin.NewAdminService(llmService, docRepo)
+	{
+		services := adminGroup.Group("/models")
+		services.GET("", adminService.ListModels)
+		services.GET("/running", adminService.RunningModels)
+		services.GET("/active", adminService.ActiveModels)
+		services.PUT("/active", adminService.SwitchModels)
+		services.POST("/pull", adminService.PullModel)
+		services.GET("/:name", adminService.ShowModel)
+		services.POST("/:name/load", adminService.LoadModel)
+		services.POST("/:name/unload", adminService.UnloadModel)
+	}
+	{
+		services := adminGroup.Group("/llm")
+		services.GET("/nodes", adminService.Nodes)
+		services.GET("/scheduler", adminService.Scheduler)
 	}
 	return e
 }
+
+func purgeExpiredRequests(ctx context.Context, repo *postgres.IdempotencyRepository) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := repo.DeleteExpired(ctx); err != nil {
+				log.Printf("purge expired request ids: %v", err)
+			}
+		}
+	}
+}
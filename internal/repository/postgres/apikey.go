@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ai-service/internal/service/apikey"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+type APIKeyRepository struct {
+	db *DB
+}
+
+func NewAPIKeyRepository(db *DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *apikey.Key) error {
+	query := `
+		INSERT INTO api_key (key_id, user_id, name, prefix, key_hash)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+	err := r.db.Pool.QueryRow(ctx, query, key.ID, key.UserID, key.Name, key.Prefix, key.Hash).
+		Scan(&key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create api key: %w", err)
+	}
+	return nil
+}
+
+// ListByUser returns the keys of a user, revoked ones included, newest
+// first.
+func (r *APIKeyRepository) ListByUser(ctx context.Context, userID string) ([]*apikey.Key, error) {
+	query := `
+		SELECT key_id, user_id, name, prefix, created_at, last_used_at, revoked_at
+		FROM api_key WHERE user_id = $1
+		ORDER BY created_at DESC`
+	rows, err := r.db.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*apikey.Key{}
+	for rows.Next() {
+		k := new(apikey.Key)
+		if err := rows.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// Authenticate returns the owner of the key with the given hash and marks
+// the key as used. Revoked keys are not found.
+func (r *APIKeyRepository) Authenticate(ctx context.Context, hash string) (string, error) {
+	query := `
+		UPDATE api_key SET last_used_at = now()
+		WHERE key_hash = $1 AND revoked_at IS NULL
+		RETURNING user_id`
+	var userID string
+	err := r.db.Pool.QueryRow(ctx, query, hash).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("authenticate api key: %w", err)
+	}
+	return userID, nil
+}
+
+// Revoke disables a key of the user for good. It returns
+// ErrAPIKeyNotFound for unknown keys, keys of other users and keys that
+// are already revoked.
+func (r *APIKeyRepository) Revoke(ctx context.Context, userID, id string) error {
+	query := `
+		UPDATE api_key SET revoked_at = now()
+		WHERE key_id = $1 AND user_id = $2 AND revoked_at IS NULL`
+	tag, err := r.db.Pool.Exec(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
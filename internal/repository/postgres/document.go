@@ -2,11 +2,18 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"ai-service/internal/service/document"
+	"github.com/jackc/pgx/v5"
 )
 
+var ErrDocumentNotFound = errors.New("document not found")
+
+const documentColumns = `user_id, document_id, document_name, tags, priority,
+	status, progress, COALESCE(error, ''), report`
+
 type DocumentRepository struct {
 	db *DB
 }
@@ -16,19 +23,39 @@ func NewDocumentRepository(db *DB) *DocumentRepository {
 }
 
 func (r *DocumentRepository) Create(ctx context.Context, doc *document.Document) error {
+	return createDocument(ctx, r.db.Pool, doc)
+}
+
+// querier is the pool or a transaction.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// createDocument inserts doc. A document without a status is taken as
+// indexed.
+func createDocument(ctx context.Context, q querier, doc *document.Document) error {
+	if doc.Status == "" {
+		doc.Status, doc.Progress = document.StatusIndexed, 100
+	}
+	tags := doc.Tags
+	if tags == nil {
+		tags = []string{}
+	}
 	query := `
-		INSERT INTO document (user_id, document_id, document_name)
-		VALUES ($1, $2, $3)
+		INSERT INTO document (user_id, document_id, document_name, tags, priority, status, progress, error, report)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9)
 		RETURNING document_id`
-	return r.db.Pool.QueryRow(ctx, query, doc.UserID, doc.DocumentID, doc.DocumentName).
+	return q.QueryRow(ctx, query, doc.UserID, doc.DocumentID, doc.DocumentName, tags, doc.Priority,
+		doc.Status, doc.Progress, doc.Error, doc.Report).
 		Scan(&doc.DocumentID)
 }
 
 func (r *DocumentRepository) GetByID(ctx context.Context, id string) (*document.Document, error) {
-	query := `SELECT user_id, document_id, document_name FROM document WHERE document_id = $1`
-	row := r.db.Pool.QueryRow(ctx, query, id)
-	d := new(document.Document)
-	err := row.Scan(&d.UserID, &d.DocumentID, &d.DocumentName)
+	query := `SELECT ` + documentColumns + ` FROM document WHERE document_id = $1`
+	d, err := scanDocument(r.db.Pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrDocumentNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("get document: %w", err)
 	}
@@ -36,7 +63,7 @@ func (r *DocumentRepository) GetByID(ctx context.Context, id string) (*document.
 }
 
 func (r *DocumentRepository) ListByUser(ctx context.Context, userID string) ([]*document.Document, error) {
-	query := `SELECT user_id, document_id, document_name FROM document WHERE user_id = $1`
+	query := `SELECT ` + documentColumns + ` FROM document WHERE user_id = $1`
 	rows, err := r.db.Pool.Query(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("list document: %w", err)
@@ -45,13 +72,76 @@ func (r *DocumentRepository) ListByUser(ctx context.Context, userID string) ([]*
 
 	var docs []*document.Document
 	for rows.Next() {
-		d := new(document.Document)
-		if err := rows.Scan(&d.UserID, &d.DocumentID, &d.DocumentName); err != nil {
+		d, err := scanDocument(rows)
+		if err != nil {
 			return nil, err
 		}
 		docs = append(docs, d)
 	}
-	return docs, nil
+	return docs, rows.Err()
+}
+
+func scanDocument(row pgx.Row) (*document.Document, error) {
+	d := new(document.Document)
+	err := row.Scan(&d.UserID, &d.DocumentID, &d.DocumentName, &d.Tags, &d.Priority,
+		&d.Status, &d.Progress, &d.Error, &d.Report)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// SetProgress moves a document to status at progress percent and clears
+// the error of an earlier attempt.
+func (r *DocumentRepository) SetProgress(ctx context.Context, id, status string, progress int) error {
+	query := `
+		UPDATE document SET status = $2, progress = $3, error = NULL, updated_at = now()
+		WHERE document_id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, id, status, progress)
+	return err
+}
+
+// Fail records why the ingestion of a document stopped. With retry the
+// document goes back to the queue, otherwise it is failed.
+func (r *DocumentRepository) Fail(ctx context.Context, id, message string, retry bool) error {
+	status := document.StatusFailed
+	if retry {
+		status = document.StatusQueued
+	}
+	query := `
+		UPDATE document SET status = $2, progress = 0, error = $3, updated_at = now()
+		WHERE document_id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, id, status, message)
+	return err
+}
+
+// Indexed marks a document as searchable with its extraction report. A
+// document uploaded without a name takes title. It returns
+// ErrDocumentNotFound when the document was deleted meanwhile.
+func (r *DocumentRepository) Indexed(ctx context.Context, id, title string, report *document.Report) error {
+	query := `
+		UPDATE document SET status = $2, progress = 100, error = NULL, report = $3,
+		    document_name = COALESCE(NULLIF(document_name, ''), $4), updated_at = now()
+		WHERE document_id = $1`
+	tag, err := r.db.Pool.Exec(ctx, query, id, document.StatusIndexed, report, title)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDocumentNotFound
+	}
+	return nil
+}
+
+// CountIndexed counts the documents whose chunks are or will be in the
+// vector store: indexed ones and those still being ingested.
+func (r *DocumentRepository) CountIndexed(ctx context.Context) (int, error) {
+	var n int
+	query := `SELECT count(*) FROM document WHERE status <> $1`
+	if err := r.db.Pool.QueryRow(ctx, query, document.StatusFailed).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count documents: %w", err)
+	}
+	return n, nil
 }
 
 func (r *DocumentRepository) Delete(ctx context.Context, id string) error {
@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ai-service/internal/service/exchange"
+	"ai-service/internal/service/ollama"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrExchangeNotFound = errors.New("message not found")
+
+type ExchangeRepository struct {
+	db *DB
+}
+
+func NewExchangeRepository(db *DB) *ExchangeRepository {
+	return &ExchangeRepository{db: db}
+}
+
+func (r *ExchangeRepository) Create(ctx context.Context, e *exchange.Exchange) error {
+	chunks := e.Chunks
+	if chunks == nil {
+		chunks = []ollama.Source{}
+	}
+	query := `
+		INSERT INTO chat_exchange (message_id, rquid, user_id, question, chunks, answer, model)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7)
+		RETURNING created_at`
+	return r.db.Pool.QueryRow(ctx, query, e.MessageID, e.RqUID, e.UserID, e.Question, chunks, e.Answer, e.Model).
+		Scan(&e.CreatedAt)
+}
+
+func (r *ExchangeRepository) GetByID(ctx context.Context, userID, messageID string) (*exchange.Exchange, error) {
+	query := `
+		SELECT message_id, COALESCE(rquid, ''), user_id, question, chunks, answer, model, created_at
+		FROM chat_exchange WHERE message_id = $1 AND user_id = $2`
+	return scanExchange(r.db.Pool.QueryRow(ctx, query, messageID, userID))
+}
+
+// GetByRqUID returns the latest exchange the user made under the given
+// request UID.
+func (r *ExchangeRepository) GetByRqUID(ctx context.Context, userID, rquid string) (*exchange.Exchange, error) {
+	query := `
+		SELECT message_id, COALESCE(rquid, ''), user_id, question, chunks, answer, model, created_at
+		FROM chat_exchange WHERE rquid = $1 AND user_id = $2
+		ORDER BY created_at DESC LIMIT 1`
+	return scanExchange(r.db.Pool.QueryRow(ctx, query, rquid, userID))
+}
+
+func scanExchange(row pgx.Row) (*exchange.Exchange, error) {
+	e := new(exchange.Exchange)
+	err := row.Scan(&e.MessageID, &e.RqUID, &e.UserID, &e.Question, &e.Chunks, &e.Answer, &e.Model, &e.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrExchangeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get exchange: %w", err)
+	}
+	return e, nil
+}
+
+// SaveFeedback stores the user's rating of a message, replacing any rating
+// they gave it before.
+func (r *ExchangeRepository) SaveFeedback(ctx context.Context, f *exchange.Feedback) error {
+	query := `
+		INSERT INTO chat_feedback (feedback_id, message_id, user_id, rating, comment, wrong_source)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		ON CONFLICT (message_id, user_id) DO UPDATE
+		SET rating = EXCLUDED.rating, comment = EXCLUDED.comment,
+		    wrong_source = EXCLUDED.wrong_source, created_at = now()
+		RETURNING feedback_id, created_at`
+	return r.db.Pool.QueryRow(ctx, query, f.FeedbackID, f.MessageID, f.UserID, f.Rating, f.Comment, f.WrongSource).
+		Scan(&f.FeedbackID, &f.CreatedAt)
+}
+
+// ListNegative returns feedback with a thumbs down or a wrong-source flag,
+// newest first, joined with the rated exchange.
+func (r *ExchangeRepository) ListNegative(ctx context.Context, limit, offset int) ([]*exchange.Review, error) {
+	query := `
+		SELECT f.feedback_id, f.message_id, f.user_id, f.rating, COALESCE(f.comment, ''), f.wrong_source, f.created_at,
+		       e.message_id, COALESCE(e.rquid, ''), e.user_id, e.question, e.chunks, e.answer, e.model, e.created_at
+		FROM chat_feedback f
+		JOIN chat_exchange e ON e.message_id = f.message_id
+		WHERE f.rating < 0 OR f.wrong_source
+		ORDER BY f.created_at DESC
+		LIMIT $1 OFFSET $2`
+	rows, err := r.db.Pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list feedback: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []*exchange.Review
+	for rows.Next() {
+		rv := new(exchange.Review)
+		f, e := &rv.Feedback, &rv.Exchange
+		if err := rows.Scan(&f.FeedbackID, &f.MessageID, &f.UserID, &f.Rating, &f.Comment, &f.WrongSource, &f.CreatedAt,
+			&e.MessageID, &e.RqUID, &e.UserID, &e.Question, &e.Chunks, &e.Answer, &e.Model, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, rv)
+	}
+	return reviews, rows.Err()
+}
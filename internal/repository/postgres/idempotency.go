@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-service/internal/service/idempotency"
+	"github.com/jackc/pgx/v5"
+)
+
+// abandonAfter is how long an unfinished reservation blocks retries before
+// it is assumed that the instance processing it died.
+const abandonAfter = 10 * time.Minute
+
+type IdempotencyRepository struct {
+	db  *DB
+	ttl time.Duration
+}
+
+func NewIdempotencyRepository(db *DB, ttl time.Duration) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db, ttl: ttl}
+}
+
+// Reserve claims the request ID for the caller. It returns nil when the
+// caller should process the request, the stored record when the request was
+// already completed, or idempotency.ErrInProgress / idempotency.ErrConflict.
+// Expired records and reservations abandoned mid-flight are taken over as
+// if they did not exist.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, rec *idempotency.Record) (*idempotency.Record, error) {
+	rec.ExpiresAt = time.Now().Add(r.ttl)
+	query := `
+		INSERT INTO idempotency_key (request_id, user_id, scope, payload_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, scope, request_id) DO UPDATE
+		SET payload_hash = EXCLUDED.payload_hash, status_code = NULL, response = NULL,
+		    created_at = now(), expires_at = EXCLUDED.expires_at
+		WHERE idempotency_key.expires_at < now()
+		   OR (idempotency_key.status_code IS NULL AND idempotency_key.created_at < $6)
+		RETURNING request_id`
+	var id string
+	err := r.db.Pool.QueryRow(ctx, query, rec.RequestID, rec.UserID, rec.Scope, rec.PayloadHash, rec.ExpiresAt,
+		time.Now().Add(-abandonAfter)).Scan(&id)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("reserve request id: %w", err)
+	}
+
+	query = `
+		SELECT payload_hash, COALESCE(status_code, 0), response, expires_at
+		FROM idempotency_key WHERE user_id = $1 AND scope = $2 AND request_id = $3`
+	existing := &idempotency.Record{RequestID: rec.RequestID, UserID: rec.UserID, Scope: rec.Scope}
+	err = r.db.Pool.QueryRow(ctx, query, rec.UserID, rec.Scope, rec.RequestID).
+		Scan(&existing.PayloadHash, &existing.StatusCode, &existing.Response, &existing.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("get request id: %w", err)
+	}
+	return rec.Replay(existing)
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, rec *idempotency.Record) error {
+	query := `
+		UPDATE idempotency_key SET status_code = $4, response = $5
+		WHERE user_id = $1 AND scope = $2 AND request_id = $3`
+	_, err := r.db.Pool.Exec(ctx, query, rec.UserID, rec.Scope, rec.RequestID, rec.StatusCode, rec.Response)
+	return err
+}
+
+// Release drops an unfinished reservation so the client can retry after a failure.
+func (r *IdempotencyRepository) Release(ctx context.Context, rec *idempotency.Record) error {
+	query := `
+		DELETE FROM idempotency_key
+		WHERE user_id = $1 AND scope = $2 AND request_id = $3 AND status_code IS NULL`
+	_, err := r.db.Pool.Exec(ctx, query, rec.UserID, rec.Scope, rec.RequestID)
+	return err
+}
+
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := r.db.Pool.Exec(ctx, `DELETE FROM idempotency_key WHERE expires_at < now()`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
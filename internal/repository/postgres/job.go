@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-service/internal/service/document"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrLeaseLost means the lease of a job ran out and another worker claimed
+// it, or the job is gone.
+var ErrLeaseLost = errors.New("job lease lost")
+
+// JobRepository is the ingestion queue. A claimed job is leased to one
+// worker; a lease that runs out, because the worker died, frees the job
+// for another one.
+type JobRepository struct {
+	db *DB
+}
+
+func NewJobRepository(db *DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// Enqueue stores doc as queued together with its job, in one transaction.
+func (r *JobRepository) Enqueue(ctx context.Context, doc *document.Document, job *document.Job) error {
+	doc.Status, doc.Progress = document.StatusQueued, 0
+	return pgx.BeginFunc(ctx, r.db.Pool, func(tx pgx.Tx) error {
+		if err := createDocument(ctx, tx, doc); err != nil {
+			return fmt.Errorf("create document: %w", err)
+		}
+		query := `
+			INSERT INTO ingestion_job (document_id, user_id, filename, data, priority)
+			VALUES ($1, $2, $3, $4, $5)`
+		if _, err := tx.Exec(ctx, query, job.DocumentID, job.UserID, job.Filename, job.Data, job.Priority); err != nil {
+			return fmt.Errorf("enqueue job: %w", err)
+		}
+		return nil
+	})
+}
+
+// Claim leases the next free job, priority documents first, or returns
+// nil when the queue is empty.
+func (r *JobRepository) Claim(ctx context.Context, lease time.Duration) (*document.Job, error) {
+	query := `
+		UPDATE ingestion_job SET attempts = attempts + 1, locked_until = now() + make_interval(secs => $1)
+		WHERE document_id = (
+			SELECT document_id FROM ingestion_job
+			WHERE locked_until IS NULL OR locked_until < now()
+			ORDER BY priority DESC, created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED)
+		RETURNING document_id, user_id, filename, data, priority, attempts`
+	job := new(document.Job)
+	err := r.db.Pool.QueryRow(ctx, query, lease.Seconds()).
+		Scan(&job.DocumentID, &job.UserID, &job.Filename, &job.Data, &job.Priority, &job.Attempts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("claim job: %w", err)
+	}
+	return job, nil
+}
+
+// Lease keeps a job from being claimed for d: to renew the lease while it
+// is worked on, or to delay a retry.
+func (r *JobRepository) Lease(ctx context.Context, id string, d time.Duration) error {
+	query := `UPDATE ingestion_job SET locked_until = now() + make_interval(secs => $2) WHERE document_id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, id, d.Seconds())
+	return err
+}
+
+// Renew extends the lease of a job that is worked on. A claim counts an
+// attempt, so a job whose attempts moved on was claimed by another worker;
+// Renew then returns ErrLeaseLost.
+func (r *JobRepository) Renew(ctx context.Context, job *document.Job, d time.Duration) error {
+	query := `
+		UPDATE ingestion_job SET locked_until = now() + make_interval(secs => $3)
+		WHERE document_id = $1 AND attempts = $2 AND locked_until IS NOT NULL`
+	tag, err := r.db.Pool.Exec(ctx, query, job.DocumentID, job.Attempts, d.Seconds())
+	if err != nil {
+		return fmt.Errorf("renew job lease: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Release frees a job at once without counting the attempt, for a worker
+// that is shutting down.
+func (r *JobRepository) Release(ctx context.Context, id string) error {
+	query := `
+		UPDATE ingestion_job SET locked_until = NULL, attempts = GREATEST(attempts - 1, 0)
+		WHERE document_id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, id)
+	return err
+}
+
+// Delete removes a job that is done, failed for good or whose document
+// was deleted.
+func (r *JobRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM ingestion_job WHERE document_id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, id)
+	return err
+}
@@ -2,11 +2,14 @@ package milvus
 
 import (
 	"ai-service/internal/repository/vector"
+	"ai-service/internal/service/document"
 	"ai-service/internal/util/config"
 	"context"
 	"fmt"
 	"github.com/milvus-io/milvus-sdk-go/v2/client"
 	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -35,11 +38,11 @@ func (r Repository) GetTopK(ctx context.Context, orgID string, k int, search []f
 		option.IgnoreGrowing = false
 	})
 	searchResult, err := r.milvus.Search(
-		ctx,              // ctx
-		orgID,            // CollectionName
-		[]string{},       // partitionNames
-		"",               // expr
-		[]string{"text"}, // outputFields
+		ctx,            // ctx
+		orgID,          // CollectionName
+		[]string{},     // partitionNames
+		"",             // expr
+		outputFields(), // outputFields
 		[]entity.Vector{entity.FloatVector(search)}, // vectors
 		"embedding", // vectorField
 		entity.L2,   // metricType
@@ -54,15 +57,38 @@ func (r Repository) GetTopK(ctx context.Context, orgID string, k int, search []f
 	return searchResult, err
 }
 
-func (r Repository) SaveDoc(ctx context.Context, orgID, docID string, chunks []string, embeddings [][][]float32) error {
+func (r Repository) SaveDoc(ctx context.Context, orgID, docID string, chunks []document.Chunk, embeddings [][][]float32) error {
 	orgID = "_" + strings.ReplaceAll(orgID, "-", "")
-	ids := make([]string, len(embeddings))
-	for i, _ := range ids {
-		ids[i] = docID
+	var (
+		ids, docIDs, texts, sections []string
+		indexes, starts, ends, page  []int64
+	)
+	for _, chunk := range chunks {
+		// Search hits are deduplicated by primary key, so every chunk
+		// needs its own.
+		ids = append(ids, fmt.Sprintf("%s#%d", docID, chunk.Index))
+		docIDs = append(docIDs, docID)
+		texts = append(texts, chunk.Text)
+		indexes = append(indexes, int64(chunk.Index))
+		starts = append(starts, int64(chunk.Start))
+		ends = append(ends, int64(chunk.End))
+		page = append(page, int64(chunk.Page))
+		sections = append(sections, chunk.Section)
 	}
 	idColumn := entity.NewColumnVarChar("id", ids)
-	textColumn := entity.NewColumnVarChar("text", chunks)
-	embeddingColumn := entity.NewColumnFloatVector("embedding", 3072, bind(embeddings))
+	// Collections created before doc_id was in the schema keep it in the
+	// dynamic field.
+	docIDColumn := entity.NewColumnVarChar(vector.DocIDField, docIDs)
+	textColumn := entity.NewColumnVarChar("text", texts)
+	// Not in the schema, so they go to the dynamic field.
+	metaColumns := []entity.Column{
+		entity.NewColumnInt64("chunk_index", indexes),
+		entity.NewColumnInt64("start", starts),
+		entity.NewColumnInt64("end", ends),
+		entity.NewColumnInt64("page", page),
+		entity.NewColumnVarChar("section", sections),
+	}
+	embeddingColumn := entity.NewColumnFloatVector("embedding", vector.Dim, bind(embeddings))
 
 	ok, err := r.milvus.HasCollection(ctx, orgID)
 	if err != nil {
@@ -97,12 +123,10 @@ func (r Repository) SaveDoc(ctx context.Context, orgID, docID string, chunks []s
 		}
 	}
 	_, err = r.milvus.Insert(
-		ctx,      // ctx
-		orgID,    // CollectionName
-		"",       // partitionName
-		idColumn, // columnarData
-		textColumn,
-		embeddingColumn, // columnarData
+		ctx,   // ctx
+		orgID, // CollectionName
+		"",    // partitionName
+		append([]entity.Column{idColumn, docIDColumn, textColumn, embeddingColumn}, metaColumns...)..., // columnarData
 	)
 	if err != nil {
 		return err
@@ -115,6 +139,24 @@ func (r Repository) SaveDoc(ctx context.Context, orgID, docID string, chunks []s
 	return nil
 }
 
+func outputFields() []string {
+	return append([]string{"text", vector.DocIDField}, vector.ChunkFields...)
+}
+
+// docExpr matches the chunks of a document. Chunks stored before doc_id
+// existed carry the document id as their primary key.
+func docExpr(docID string) string {
+	docID = quote(strings.Trim(docID, "/"))
+	return fmt.Sprintf("%s == %s or id == %s", vector.DocIDField, docID, docID)
+}
+
+var literalEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// quote makes s a string literal of a filter expression.
+func quote(s string) string {
+	return "'" + literalEscaper.Replace(s) + "'"
+}
+
 func bind(embeddings [][][]float32) [][]float32 {
 	result := make([][]float32, 0)
 	for _, embedding := range embeddings {
@@ -129,15 +171,48 @@ func bind(embeddings [][][]float32) [][]float32 {
 
 func emptyDym() []float32 {
 	var result []float32
-	for i := 0; i < 3072; i++ {
+	for i := 0; i < vector.Dim; i++ {
 		result = append(result, 0)
 	}
 	return result
 }
 
+func (r Repository) GetChunks(ctx context.Context, orgID string, docID string) ([]document.Chunk, error) {
+	orgID = "_" + strings.ReplaceAll(orgID, "-", "")
+	expr := docExpr(docID)
+	resultSet, err := r.milvus.Query(
+		ctx,            // ctx
+		orgID,          // CollectionName
+		[]string{},     // partitionNames
+		expr,           // expr
+		outputFields(), // outputFields
+		client.WithSearchQueryConsistencyLevel(entity.ClStrong),
+	)
+	if err != nil {
+		return nil, err
+	}
+	column := resultSet.GetColumn("text")
+	if column == nil {
+		return nil, nil
+	}
+	chunks := make([]document.Chunk, 0, column.Len())
+	for i := 0; i < column.Len(); i++ {
+		s, err := column.GetAsString(i)
+		if err != nil {
+			return nil, err
+		}
+		chunk := vector.ChunkMeta(resultSet, i)
+		chunk.Text = s
+		chunks = append(chunks, chunk)
+	}
+	// Query results come back in no particular order.
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].Index < chunks[j].Index })
+	return chunks, nil
+}
+
 func (r Repository) DeleteDoc(ctx context.Context, orgID string, id string) error {
 	orgID = "_" + strings.ReplaceAll(orgID, "-", "")
-	expr := fmt.Sprintf("id == '%s'", strings.Trim(id, "/"))
+	expr := docExpr(id)
 	err := r.milvus.Delete(
 		ctx,   // ctx
 		orgID, // collection name
@@ -164,6 +239,13 @@ func (r Repository) createSchema(orgID string) *entity.Schema {
 					"max_length": "10000",
 				},
 			},
+			{
+				Name:     vector.DocIDField,
+				DataType: entity.FieldTypeVarChar,
+				TypeParams: map[string]string{
+					"max_length": "256",
+				},
+			},
 			{
 				Name:       "text",
 				DataType:   entity.FieldTypeVarChar,
@@ -177,7 +259,7 @@ func (r Repository) createSchema(orgID string) *entity.Schema {
 				Name:     "embedding",
 				DataType: entity.FieldTypeFloatVector,
 				TypeParams: map[string]string{
-					"dim": "3072",
+					"dim": strconv.Itoa(vector.Dim),
 				},
 			},
 		},
@@ -0,0 +1,19 @@
+package milvus
+
+import "testing"
+
+func TestDocExpr(t *testing.T) {
+	tests := []struct {
+		docID, want string
+	}{
+		{"0b6f6a3e-0c5e-4d7b-9a43-3c1f3b1f7a11", `doc_id == '0b6f6a3e-0c5e-4d7b-9a43-3c1f3b1f7a11' or id == '0b6f6a3e-0c5e-4d7b-9a43-3c1f3b1f7a11'`},
+		{"/doc/", `doc_id == 'doc' or id == 'doc'`},
+		{"x' or doc_id != '", `doc_id == 'x\' or doc_id != \'' or id == 'x\' or doc_id != \''`},
+		{`x\' or id != '`, `doc_id == 'x\\\' or id != \'' or id == 'x\\\' or id != \''`},
+	}
+	for _, tt := range tests {
+		if got := docExpr(tt.docID); got != tt.want {
+			t.Errorf("docExpr(%q) = %s, want %s", tt.docID, got, tt.want)
+		}
+	}
+}
@@ -1,12 +1,61 @@
 package vector
 
 import (
+	"ai-service/internal/service/document"
 	"context"
 	"github.com/milvus-io/milvus-sdk-go/v2/client"
 )
 
+// Dim is the embedding dimension of every collection.
+const Dim = 3072
+
+// DocIDField holds the id of the document a chunk belongs to. The primary
+// key is unique per chunk.
+const DocIDField = "doc_id"
+
+// ChunkFields are the dynamic fields stored with every chunk for citations.
+var ChunkFields = []string{"chunk_index", "start", "end", "page", "section"}
+
 type VectorDB interface {
 	GetTopK(ctx context.Context, orgID string, k int, search []float32) ([]client.SearchResult, error)
+	GetChunks(ctx context.Context, orgID string, docID string) ([]document.Chunk, error)
 	DeleteDoc(ctx context.Context, orgID string, id string) error
-	SaveDoc(ctx context.Context, orgID, docID string, chunks []string, embeddings [][][]float32) error
+	SaveDoc(ctx context.Context, orgID, docID string, chunks []document.Chunk, embeddings [][][]float32) error
+}
+
+// DocumentID returns the document of row i of a search result. Chunks
+// stored before doc_id existed have the document id as their primary key.
+func DocumentID(result client.SearchResult, i int) string {
+	if column := result.Fields.GetColumn(DocIDField); column != nil {
+		if id, err := column.GetAsString(i); err == nil && id != "" {
+			return id
+		}
+	}
+	if result.IDs != nil && i < result.IDs.Len() {
+		id, _ := result.IDs.GetAsString(i)
+		return id
+	}
+	return ""
+}
+
+// ChunkMeta reads the citation fields of row i. Chunks stored before the
+// fields existed come back with zero values.
+func ChunkMeta(fields client.ResultSet, i int) document.Chunk {
+	number := func(name string) int {
+		if column := fields.GetColumn(name); column != nil {
+			if v, err := column.GetAsInt64(i); err == nil {
+				return int(v)
+			}
+		}
+		return 0
+	}
+	var chunk document.Chunk
+	chunk.Index = number("chunk_index")
+	chunk.Start = number("start")
+	chunk.End = number("end")
+	chunk.Page = number("page")
+	if column := fields.GetColumn("section"); column != nil {
+		chunk.Section, _ = column.GetAsString(i)
+	}
+	return chunk
 }
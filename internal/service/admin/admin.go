@@ -0,0 +1,29 @@
+package admin
+
+import (
+	"ai-service/internal/repository/postgres"
+	"ai-service/internal/service/ollama"
+	"github.com/labstack/echo/v4"
+)
+
+type AdminService interface {
+	ListModels(c echo.Context) error
+	RunningModels(c echo.Context) error
+	ShowModel(c echo.Context) error
+	PullModel(c echo.Context) error
+	LoadModel(c echo.Context) error
+	UnloadModel(c echo.Context) error
+	ActiveModels(c echo.Context) error
+	SwitchModels(c echo.Context) error
+	Nodes(c echo.Context) error
+	Scheduler(c echo.Context) error
+}
+
+type adminService struct {
+	llm       ollama.LLMService
+	documents *postgres.DocumentRepository
+}
+
+func NewAdminService(llm ollama.LLMService, documents *postgres.DocumentRepository) AdminService {
+	return &adminService{llm: llm, documents: documents}
+}
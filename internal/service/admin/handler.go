@@ -0,0 +1,237 @@
+package admin
+
+import (
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/config"
+	"ai-service/internal/util/errors"
+	"encoding/json"
+	stdErrors "errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/url"
+)
+
+const defaultKeepAlive = "-1"
+
+// ListModels
+//
+// @Description List models installed in Ollama
+// @Summary	List models
+// @Tags admin
+// @Produce	json
+// @Success	200				{object}		ollama.ModelList
+// @Router /api/v1/admin/models 	[get]
+func (a *adminService) ListModels(c echo.Context) error {
+	list, err := a.llm.ListModels(c.Request().Context())
+	if err != nil {
+		return errors.NewCustomErrorResponse(ollama.HTTPStatus(err), err.Error())
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// RunningModels
+//
+// @Description List models loaded in memory
+// @Summary	List running models
+// @Tags admin
+// @Produce	json
+// @Success	200				{object}		ollama.ModelList
+// @Router /api/v1/admin/models/running 	[get]
+func (a *adminService) RunningModels(c echo.Context) error {
+	list, err := a.llm.RunningModels(c.Request().Context())
+	if err != nil {
+		return errors.NewCustomErrorResponse(ollama.HTTPStatus(err), err.Error())
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// ShowModel
+//
+// @Description Show model details
+// @Summary	Show model details
+// @Tags admin
+// @Produce	json
+// @Param		name	path	string	true	"model name"
+// @Success	200				{object}		ollama.ModelInfo
+// @Router /api/v1/admin/models/{name} 	[get]
+func (a *adminService) ShowModel(c echo.Context) error {
+	name, err := modelName(c)
+	if err != nil {
+		return err
+	}
+	info, err := a.llm.ShowModel(c.Request().Context(), name)
+	if stdErrors.Is(err, ollama.ErrModelNotFound) {
+		return errors.NewNotFoundErrorRsp(err.Error())
+	}
+	if err != nil {
+		return errors.NewCustomErrorResponse(ollama.HTTPStatus(err), err.Error())
+	}
+	return c.JSON(http.StatusOK, info)
+}
+
+// PullModel
+//
+// @Description Pull a model, streaming progress as JSON Lines
+// @Summary	Pull model
+// @Tags admin
+// @Accept json
+// @Produce	application/x-ndjson
+// @Param		request	body		PullRequest	true	"body param"
+// @Success	200				{object}		ollama.PullProgress
+// @Router /api/v1/admin/models/pull 	[post]
+func (a *adminService) PullModel(c echo.Context) error {
+	var dataReq PullRequest
+	if err := c.Bind(&dataReq); err != nil {
+		return errors.NewBadRequestErrorRsp(err.Error())
+	}
+	if err := c.Validate(&dataReq); err != nil {
+		return errors.NewBadRequestErrorRsp(err.Error())
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	res.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(res)
+	err := a.llm.PullModel(c.Request().Context(), dataReq.Name, func(progress *ollama.PullProgress) error {
+		if err := enc.Encode(progress); err != nil {
+			return err
+		}
+		res.Flush()
+		return nil
+	})
+	if err != nil {
+		// Headers are already sent, so the error is the last progress line.
+		return enc.Encode(ollama.PullProgress{Status: "error", Error: err.Error()})
+	}
+	return nil
+}
+
+// LoadModel
+//
+// @Description Preload a model into memory
+// @Summary	Load model
+// @Tags admin
+// @Accept json
+// @Param		name	path	string	true	"model name"
+// @Param		request	body		LoadRequest	false	"body param"
+// @Success	204
+// @Router /api/v1/admin/models/{name}/load 	[post]
+func (a *adminService) LoadModel(c echo.Context) error {
+	name, err := modelName(c)
+	if err != nil {
+		return err
+	}
+	var dataReq LoadRequest
+	if err := c.Bind(&dataReq); err != nil {
+		return errors.NewBadRequestErrorRsp(err.Error())
+	}
+	if dataReq.KeepAlive == "" {
+		dataReq.KeepAlive = defaultKeepAlive
+	}
+	if err := (config.GenerationOptions{KeepAlive: dataReq.KeepAlive}).Validate(); err != nil {
+		return errors.NewBadRequestErrorRsp(err.Error())
+	}
+	return a.keepAlive(c, name, dataReq.KeepAlive)
+}
+
+// UnloadModel
+//
+// @Description Unload a model from memory
+// @Summary	Unload model
+// @Tags admin
+// @Param		name	path	string	true	"model name"
+// @Success	204
+// @Router /api/v1/admin/models/{name}/unload 	[post]
+func (a *adminService) UnloadModel(c echo.Context) error {
+	name, err := modelName(c)
+	if err != nil {
+		return err
+	}
+	return a.keepAlive(c, name, "0")
+}
+
+func (a *adminService) keepAlive(c echo.Context, name, keepAlive string) error {
+	err := a.llm.KeepAlive(c.Request().Context(), name, keepAlive)
+	if stdErrors.Is(err, ollama.ErrModelNotFound) {
+		return errors.NewNotFoundErrorRsp(err.Error())
+	}
+	if err != nil {
+		return errors.NewCustomErrorResponse(ollama.HTTPStatus(err), err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ActiveModels
+//
+// @Description Models currently used for chat and embeddings
+// @Summary	Active models
+// @Tags admin
+// @Produce	json
+// @Success	200				{object}		ollama.ActiveModels
+// @Router /api/v1/admin/models/active 	[get]
+func (a *adminService) ActiveModels(c echo.Context) error {
+	return c.JSON(http.StatusOK, a.llm.Models())
+}
+
+// SwitchModels
+//
+// @Description Switch the chat and/or embedding model after a health check. The embedding model can only be switched while no documents are indexed
+// @Summary	Switch active models
+// @Tags admin
+// @Accept json
+// @Produce	json
+// @Param		request	body		SwitchRequest	true	"body param"
+// @Success	200				{object}		ollama.ActiveModels
+// @Router /api/v1/admin/models/active 	[put]
+func (a *adminService) SwitchModels(c echo.Context) error {
+	var dataReq SwitchRequest
+	if err := c.Bind(&dataReq); err != nil {
+		return errors.NewBadRequestErrorRsp(err.Error())
+	}
+	if err := c.Validate(&dataReq); err != nil {
+		return errors.NewBadRequestErrorRsp(err.Error())
+	}
+	err := a.llm.SwitchModels(c.Request().Context(), ollama.ActiveModels{
+		Chat:      dataReq.ChatModel,
+		Embedding: dataReq.EmbeddingModel,
+	}, a.documents.CountIndexed)
+	if stdErrors.Is(err, ollama.ErrEmbeddingInUse) {
+		return errors.NewConflictErrorRsp(err.Error())
+	}
+	if err != nil {
+		return errors.NewUnprocessableErrorRsp(err.Error())
+	}
+	return c.JSON(http.StatusOK, a.llm.Models())
+}
+
+// Nodes
+//
+// @Description Health, load and models of every Ollama node
+// @Summary	LLM node status
+// @Tags admin
+// @Produce	json
+// @Success	200				{array}		ollama.NodeStatus
+// @Router /api/v1/admin/llm/nodes 	[get]
+func (a *adminService) Nodes(c echo.Context) error {
+	return c.JSON(http.StatusOK, a.llm.Nodes())
+}
+
+func modelName(c echo.Context) (string, error) {
+	name, err := url.PathUnescape(c.Param("name"))
+	if err != nil || name == "" {
+		return "", errors.NewBadRequestErrorRsp("invalid model name")
+	}
+	return name, nil
+}
+
+// Scheduler
+//
+// @Description Concurrency, queue depth and queue wait times per priority class
+// @Summary	LLM scheduler stats
+// @Tags admin
+// @Produce	json
+// @Success	200				{object}		ollama.SchedulerStats
+// @Router /api/v1/admin/llm/scheduler 	[get]
+func (a *adminService) Scheduler(c echo.Context) error {
+	return c.JSON(http.StatusOK, a.llm.SchedulerStats())
+}
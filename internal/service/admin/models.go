@@ -0,0 +1,14 @@
+package admin
+
+type PullRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type LoadRequest struct {
+	KeepAlive string `json:"keep_alive"`
+}
+
+type SwitchRequest struct {
+	ChatModel      string `json:"chat_model" validate:"required_without=EmbeddingModel"`
+	EmbeddingModel string `json:"embedding_model" validate:"required_without=ChatModel"`
+}
@@ -0,0 +1,51 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Prefix starts every key, so a bearer token can be told from a JWT.
+const Prefix = "sk-"
+
+// Key is a long-lived credential for the OpenAI-compatible API. Only the
+// hash of the secret is stored; the secret is shown once, when issued.
+type Key struct {
+	ID         string     `db:"key_id" json:"id"`
+	UserID     string     `db:"user_id" json:"-"`
+	Name       string     `db:"name" json:"name"`
+	Prefix     string     `db:"prefix" json:"prefix"`
+	Hash       string     `db:"key_hash" json:"-"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+}
+
+// Generate returns a new secret.
+func Generate() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return Prefix + hex.EncodeToString(b), nil
+}
+
+// Hash is the stored form of secret. The secret is random, so a plain
+// SHA-256 is enough and lets the key be looked up by it.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsKey tells an API key from an access token.
+func IsKey(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
+
+// Display is the start of secret, enough for the user to recognise it.
+func Display(secret string) string {
+	return secret[:len(Prefix)+8]
+}
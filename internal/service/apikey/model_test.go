@@ -0,0 +1,29 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	a, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := Generate()
+	if a == b {
+		t.Error("two keys are equal")
+	}
+	if !IsKey(a) || len(a) != len(Prefix)+64 {
+		t.Errorf("key %q, want %s and 64 hex digits", a, Prefix)
+	}
+	if IsKey("eyJhbGciOiJIUzI1NiJ9.e30.sig") {
+		t.Error("a JWT is taken for an API key")
+	}
+	if d := Display(a); !strings.HasPrefix(a, d) || len(d) != len(Prefix)+8 {
+		t.Errorf("display %q of %q", d, a)
+	}
+	if Hash(a) == Hash(b) || Hash(a) != Hash(a) || strings.Contains(Hash(a), a[len(Prefix):]) {
+		t.Error("hashes do not tell keys apart")
+	}
+}
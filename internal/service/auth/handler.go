@@ -1,6 +1,10 @@
 package auth
 
 import (
+	"ai-service/internal/repository/postgres"
+	"ai-service/internal/service/apikey"
+	"ai-service/internal/util/middleware"
+	"errors"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"time"
@@ -126,3 +130,86 @@ func (h *AuthHandler) Logout(c echo.Context) error {
 type logoutReq struct {
 	RefreshToken string `json:"refresh_token"`
 }
+
+type APIKeyReq struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// APIKeyResp carries the secret of a new key. It is not shown again.
+type APIKeyResp struct {
+	*apikey.Key
+	Secret string `json:"secret"`
+}
+
+// CreateAPIKey
+//
+// @Description Issue an API key for the OpenAI-compatible API. Use it as a Bearer token; it does not expire until revoked
+// @Summary	create api key
+// @Tags auth
+// @Accept json
+// @Produce	json
+// @Param		request	body		APIKeyReq	true	"body param"
+// @Success	201			{object}		APIKeyResp
+// @Router /keys	[post]
+func (h *AuthHandler) CreateAPIKey(c echo.Context) error {
+	uid, ok := middleware.UserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "user not found"})
+	}
+	var req APIKeyReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	key, secret, err := h.svc.IssueAPIKey(c.Request().Context(), uid, req.Name)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, APIKeyResp{Key: key, Secret: secret})
+}
+
+// ListAPIKeys
+//
+// @Description List the API keys of the user, revoked ones included. Secrets are not returned
+// @Summary	list api keys
+// @Tags auth
+// @Produce	json
+// @Success	200			{array}		apikey.Key
+// @Router /keys	[get]
+func (h *AuthHandler) ListAPIKeys(c echo.Context) error {
+	uid, ok := middleware.UserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "user not found"})
+	}
+	keys, err := h.svc.ListAPIKeys(c.Request().Context(), uid)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey
+//
+// @Description Revoke an API key. Requests made with it are rejected from then on
+// @Summary	revoke api key
+// @Tags auth
+// @Param		id	path	string	true	"key id"
+// @Success	204
+// @Router /keys/{id}	[delete]
+func (h *AuthHandler) RevokeAPIKey(c echo.Context) error {
+	uid, ok := middleware.UserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "user not found"})
+	}
+	err := h.svc.RevokeAPIKey(c.Request().Context(), uid, c.Param("id"))
+	if errors.Is(err, postgres.ErrAPIKeyNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"ai-service/internal/repository/postgres"
+	"ai-service/internal/service/apikey"
 	user2 "ai-service/internal/service/user"
 	"context"
 	"errors"
@@ -14,14 +15,16 @@ import (
 
 type Service struct {
 	users      *postgres.UserRepository
+	keys       *postgres.APIKeyRepository
 	jwtSecret  []byte
 	accessTTL  time.Duration
 	refreshTTL time.Duration
 }
 
-func NewService(u *postgres.UserRepository, jwtSecret []byte) *Service {
+func NewService(u *postgres.UserRepository, keys *postgres.APIKeyRepository, jwtSecret []byte) *Service {
 	return &Service{
 		users:      u,
+		keys:       keys,
 		jwtSecret:  jwtSecret,
 		accessTTL:  15 * time.Minute,
 		refreshTTL: 7 * 24 * time.Hour,
@@ -147,3 +150,40 @@ func (s *Service) ParseAccessToken(tokenStr string) (string, error) {
 	}
 	return sub, nil
 }
+
+// Authenticate accepts an access token or an API key and returns the user
+// id.
+func (s *Service) Authenticate(ctx context.Context, token string) (string, error) {
+	if apikey.IsKey(token) {
+		return s.keys.Authenticate(ctx, apikey.Hash(token))
+	}
+	return s.ParseAccessToken(token)
+}
+
+// IssueAPIKey creates a key for the user. The secret is returned only
+// here; the key stores its hash.
+func (s *Service) IssueAPIKey(ctx context.Context, userID, name string) (*apikey.Key, string, error) {
+	secret, err := apikey.Generate()
+	if err != nil {
+		return nil, "", err
+	}
+	key := &apikey.Key{
+		ID:     uuid.New().String(),
+		UserID: userID,
+		Name:   name,
+		Prefix: apikey.Display(secret),
+		Hash:   apikey.Hash(secret),
+	}
+	if err := s.keys.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+func (s *Service) ListAPIKeys(ctx context.Context, userID string) ([]*apikey.Key, error) {
+	return s.keys.ListByUser(ctx, userID)
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, userID, id string) error {
+	return s.keys.Revoke(ctx, userID, id)
+}
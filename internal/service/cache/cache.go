@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"ai-service/internal/service/grounding"
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/config"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AnswerCache keeps generated answers per user so that a question close
+// enough to one already answered from the same documents is served without
+// calling the model again.
+type AnswerCache struct {
+	config config.AnswerCache
+
+	mu      sync.Mutex
+	entries map[string][]*entry
+}
+
+// Answer is a stored answer with the grounding report it got, if any.
+type Answer struct {
+	Response  ollama.ChatResponse
+	Grounding *grounding.Report
+}
+
+type entry struct {
+	variant   string
+	embedding []float32
+	documents string
+	answer    Answer
+	created   time.Time
+}
+
+func NewAnswerCache(cfg config.AnswerCache) *AnswerCache {
+	return &AnswerCache{
+		config:  cfg,
+		entries: make(map[string][]*entry),
+	}
+}
+
+func (c *AnswerCache) Enabled() bool {
+	return c != nil && c.config.Enabled
+}
+
+// Lookup returns a copy of the most similar stored answer for userID that
+// was generated under variant from exactly the documents in sources.
+func (c *AnswerCache) Lookup(userID, variant string, embedding []float32, sources []ollama.Source) (*Answer, bool) {
+	if !c.Enabled() {
+		return nil, false
+	}
+	documents := documentSet(sources)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire(userID)
+	var (
+		best      *entry
+		bestScore = c.config.Threshold
+	)
+	for _, e := range c.entries[userID] {
+		if e.variant != variant || e.documents != documents {
+			continue
+		}
+		if score := cosine(e.embedding, embedding); score >= bestScore {
+			best, bestScore = e, score
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	answer := best.answer
+	return &answer, true
+}
+
+// Store remembers answer as the answer to the question with embedding.
+func (c *AnswerCache) Store(userID, variant string, embedding []float32, answer Answer) {
+	if !c.Enabled() {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire(userID)
+	entries := append(c.entries[userID], &entry{
+		variant:   variant,
+		embedding: embedding,
+		documents: documentSet(answer.Response.Sources),
+		answer:    answer,
+		created:   time.Now(),
+	})
+	if max := c.config.MaxEntries; max > 0 && len(entries) > max {
+		entries = entries[len(entries)-max:]
+	}
+	c.entries[userID] = entries
+}
+
+// InvalidateDocument drops every answer of userID that used documentID.
+func (c *AnswerCache) InvalidateDocument(userID, documentID string) {
+	if !c.Enabled() {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	kept := c.entries[userID][:0]
+	for _, e := range c.entries[userID] {
+		if !containsDocument(e.documents, documentID) {
+			kept = append(kept, e)
+		}
+	}
+	c.entries[userID] = kept
+}
+
+func (c *AnswerCache) expire(userID string) {
+	if c.config.TTL <= 0 {
+		return
+	}
+	entries := c.entries[userID]
+	i := 0
+	for i < len(entries) && time.Since(entries[i].created) > c.config.TTL {
+		i++
+	}
+	c.entries[userID] = entries[i:]
+}
+
+// documentSet is the sorted, de-duplicated list of document IDs behind
+// sources. Uploads always get a fresh ID, so it also pins the versions.
+func documentSet(sources []ollama.Source) string {
+	seen := make(map[string]bool, len(sources))
+	ids := make([]string, 0, len(sources))
+	for _, source := range sources {
+		if !seen[source.DocumentID] {
+			seen[source.DocumentID] = true
+			ids = append(ids, source.DocumentID)
+		}
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+func containsDocument(documents, documentID string) bool {
+	for _, id := range strings.Split(documents, ",") {
+		if id == documentID {
+			return true
+		}
+	}
+	return false
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/config"
+	"testing"
+	"time"
+)
+
+func answerFrom(content string, documentIDs ...string) Answer {
+	var answer Answer
+	answer.Response.Message.Content = content
+	for _, id := range documentIDs {
+		answer.Response.Sources = append(answer.Response.Sources, ollama.Source{DocumentID: id})
+	}
+	return answer
+}
+
+func TestLookup(t *testing.T) {
+	c := NewAnswerCache(config.AnswerCache{Enabled: true, Threshold: 0.95})
+	c.Store("u1", "v1", []float32{1, 0, 0}, answerFrom("первый", "doc-b", "doc-a", "doc-b"))
+
+	sources := []ollama.Source{{DocumentID: "doc-a"}, {DocumentID: "doc-b"}}
+	tests := []struct {
+		name      string
+		user      string
+		variant   string
+		embedding []float32
+		sources   []ollama.Source
+		hit       bool
+	}{
+		{"same question", "u1", "v1", []float32{1, 0, 0}, sources, true},
+		{"close question", "u1", "v1", []float32{1, 0.1, 0}, sources, true},
+		{"different question", "u1", "v1", []float32{0, 1, 0}, sources, false},
+		{"other user", "u2", "v1", []float32{1, 0, 0}, sources, false},
+		{"other variant", "u1", "v2", []float32{1, 0, 0}, sources, false},
+		{"other documents", "u1", "v1", []float32{1, 0, 0}, sources[:1], false},
+		{"other dimensions", "u1", "v1", []float32{1, 0}, sources, false},
+	}
+	for _, tt := range tests {
+		answer, hit := c.Lookup(tt.user, tt.variant, tt.embedding, tt.sources)
+		if hit != tt.hit {
+			t.Errorf("%s: hit = %v, want %v", tt.name, hit, tt.hit)
+			continue
+		}
+		if hit && answer.Response.Message.Content != "первый" {
+			t.Errorf("%s: got %q", tt.name, answer.Response.Message.Content)
+		}
+	}
+}
+
+func TestLookupPicksMostSimilar(t *testing.T) {
+	c := NewAnswerCache(config.AnswerCache{Enabled: true, Threshold: 0.5})
+	c.Store("u1", "v1", []float32{1, 1, 0}, answerFrom("дальний", "doc-a"))
+	c.Store("u1", "v1", []float32{1, 0.1, 0}, answerFrom("ближний", "doc-a"))
+
+	answer, ok := c.Lookup("u1", "v1", []float32{1, 0, 0}, []ollama.Source{{DocumentID: "doc-a"}})
+	if !ok || answer.Response.Message.Content != "ближний" {
+		t.Fatalf("got %v, %v", answer, ok)
+	}
+}
+
+func TestStoreLimitsEntries(t *testing.T) {
+	c := NewAnswerCache(config.AnswerCache{Enabled: true, Threshold: 0.99, MaxEntries: 2})
+	c.Store("u1", "v1", []float32{1, 0, 0}, answerFrom("1", "doc-a"))
+	c.Store("u1", "v1", []float32{0, 1, 0}, answerFrom("2", "doc-a"))
+	c.Store("u1", "v1", []float32{0, 0, 1}, answerFrom("3", "doc-a"))
+
+	sources := []ollama.Source{{DocumentID: "doc-a"}}
+	if _, ok := c.Lookup("u1", "v1", []float32{1, 0, 0}, sources); ok {
+		t.Error("the oldest answer was kept")
+	}
+	if _, ok := c.Lookup("u1", "v1", []float32{0, 0, 1}, sources); !ok {
+		t.Error("the newest answer was dropped")
+	}
+}
+
+func TestExpire(t *testing.T) {
+	c := NewAnswerCache(config.AnswerCache{Enabled: true, Threshold: 0.99, TTL: time.Minute})
+	c.Store("u1", "v1", []float32{1, 0}, answerFrom("старый", "doc-a"))
+	c.entries["u1"][0].created = time.Now().Add(-2 * time.Minute)
+
+	if _, ok := c.Lookup("u1", "v1", []float32{1, 0}, []ollama.Source{{DocumentID: "doc-a"}}); ok {
+		t.Error("an expired answer was served")
+	}
+}
+
+func TestInvalidateDocument(t *testing.T) {
+	c := NewAnswerCache(config.AnswerCache{Enabled: true, Threshold: 0.99})
+	c.Store("u1", "v1", []float32{1, 0}, answerFrom("из двух", "doc-a", "doc-b"))
+	c.Store("u1", "v1", []float32{0, 1}, answerFrom("из одного", "doc-c"))
+	c.InvalidateDocument("u1", "doc-b")
+
+	if _, ok := c.Lookup("u1", "v1", []float32{1, 0}, []ollama.Source{{DocumentID: "doc-a"}, {DocumentID: "doc-b"}}); ok {
+		t.Error("an answer using the deleted document was served")
+	}
+	if _, ok := c.Lookup("u1", "v1", []float32{0, 1}, []ollama.Source{{DocumentID: "doc-c"}}); !ok {
+		t.Error("an unrelated answer was dropped")
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	var nilCache *AnswerCache
+	for _, c := range []*AnswerCache{nilCache, NewAnswerCache(config.AnswerCache{Threshold: 0.5})} {
+		c.Store("u1", "v1", []float32{1}, answerFrom("ответ", "doc-a"))
+		if _, ok := c.Lookup("u1", "v1", []float32{1}, []ollama.Source{{DocumentID: "doc-a"}}); ok {
+			t.Error("a disabled cache served an answer")
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package chat
+
+import (
+	"ai-service/internal/service/cache"
+	"ai-service/internal/service/grounding"
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/config"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// answerInput is what answer needs besides the user.
+type answerInput struct {
+	embedding []float32
+	sources   []ollama.Source
+	messages  []ollama.Message
+	options   config.GenerationOptions
+	cacheable bool
+	verify    bool
+	// onChunk, when set, receives the answer text while it is generated.
+	onChunk func(*ollama.ChatResponse) error
+}
+
+// answer serves the question from the answer cache when a close enough
+// question was already answered from the same documents, and generates a
+// new answer otherwise. With verify set the answer is checked against its
+// sources; a blocked answer comes back with its text replaced. When
+// streaming, the text has already been sent by then, so the caller has to
+// tell the client to replace it.
+func (d *chatService) answer(ctx context.Context, uid string, in answerInput, opts ...ollama.RequestOption) (*ollama.ChatResponse, *grounding.Report, bool, error) {
+	var (
+		err     error
+		variant string
+		answer  cache.Answer
+		hit     bool
+	)
+	if in.cacheable && d.answers.Enabled() {
+		variant, err = cacheVariant(d.llm.Models().Chat, in.options, in.messages)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if cached, ok := d.answers.Lookup(uid, variant, in.embedding, in.sources); ok {
+			answer, hit = *cached, true
+		}
+	}
+	switch {
+	case !hit:
+		response, err := d.generate(ctx, uid, in, opts...)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		answer.Response = *response
+	case in.onChunk != nil && !(in.verify && answer.Grounding != nil && answer.Grounding.Blocked):
+		chunk := ollama.ChatResponse{Model: answer.Response.Model, Message: answer.Response.Message}
+		if err := in.onChunk(&chunk); err != nil {
+			return nil, nil, false, err
+		}
+	}
+	store := variant != "" && !hit
+	if in.verify && answer.Grounding == nil {
+		answer.Grounding, err = d.grounding.Check(ctx, answer.Response.Message.Content, answer.Response.Sources)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		store = variant != ""
+	}
+	if store {
+		d.answers.Store(uid, variant, in.embedding, answer)
+	}
+
+	response := answer.Response
+	var report *grounding.Report
+	if in.verify {
+		report = answer.Grounding
+		if report.Blocked {
+			response.Message.Content = d.grounding.BlockMessage()
+		}
+	}
+	return &response, report, hit, nil
+}
+
+// generate asks the model, streaming partial answers to in.onChunk when it
+// is set, and returns the complete answer.
+func (d *chatService) generate(ctx context.Context, uid string, in answerInput, opts ...ollama.RequestOption) (*ollama.ChatResponse, error) {
+	if in.onChunk == nil {
+		return d.llm.Generate(ctx, uid, in.sources, in.messages, opts...)
+	}
+	var (
+		content strings.Builder
+		final   *ollama.ChatResponse
+	)
+	err := d.llm.GenerateStream(ctx, uid, in.sources, in.messages, func(chunk *ollama.ChatResponse) error {
+		content.WriteString(chunk.Message.Content)
+		if chunk.Done {
+			final = chunk
+			return nil
+		}
+		return in.onChunk(chunk)
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if final == nil {
+		return nil, fmt.Errorf("answer stream ended before the model finished")
+	}
+	final.Message.Role = "assistant"
+	final.Message.Content = content.String()
+	return final, nil
+}
+
+// cacheVariant identifies everything besides the question and the
+// documents that shapes an answer: the model, the generation options and
+// the earlier turns of the conversation.
+func cacheVariant(model string, options config.GenerationOptions, messages []ollama.Message) (string, error) {
+	raw, err := json.Marshal(struct {
+		Model    string                   `json:"model"`
+		Options  config.GenerationOptions `json:"options"`
+		Messages []ollama.Message         `json:"messages"`
+	}{model, options, messages[:len(messages)-1]})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,41 @@
+package chat
+
+import (
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/config"
+	"testing"
+)
+
+func TestCacheVariant(t *testing.T) {
+	temperature := 0.2
+	history := []ollama.Message{{Role: "user", Content: "Привет"}, {Role: "assistant", Content: "Здравствуйте"}}
+	question := func(text string) []ollama.Message {
+		return append(append([]ollama.Message{}, history...), ollama.Message{Role: "user", Content: text})
+	}
+	base, err := cacheVariant("llama3", config.GenerationOptions{}, question("Какой срок договора?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		model    string
+		options  config.GenerationOptions
+		messages []ollama.Message
+		same     bool
+	}{
+		{"other question", "llama3", config.GenerationOptions{}, question("Сколько стоит?"), true},
+		{"other model", "qwen2", config.GenerationOptions{}, question("Какой срок договора?"), false},
+		{"other options", "llama3", config.GenerationOptions{Temperature: &temperature}, question("Какой срок договора?"), false},
+		{"no history", "llama3", config.GenerationOptions{}, []ollama.Message{{Role: "user", Content: "Какой срок договора?"}}, false},
+	}
+	for _, tt := range tests {
+		variant, err := cacheVariant(tt.model, tt.options, tt.messages)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (variant == base) != tt.same {
+			t.Errorf("%s: same variant = %v, want %v", tt.name, variant == base, tt.same)
+		}
+	}
+}
@@ -2,23 +2,27 @@ package chat
 
 import (
 	"ai-service/internal/repository"
+	"ai-service/internal/repository/postgres"
+	"ai-service/internal/service/cache"
+	"ai-service/internal/service/exchange"
+	"ai-service/internal/service/grounding"
+	"ai-service/internal/service/guardrails"
+	"ai-service/internal/service/idempotency"
 	"ai-service/internal/service/ollama"
+	"ai-service/internal/service/tools"
 	"ai-service/internal/util/config"
 	"ai-service/internal/util/errors"
+	"ai-service/internal/util/jsonschema"
 	"ai-service/internal/util/middleware"
-	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/json"
+	stdErrors "errors"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
-	"io/ioutil"
+	"log"
 	"net/http"
 )
 
-const (
-	chat  = "/api/chat"
-	embed = "/api/embed"
-)
-
 type ChatService interface {
 	Chat(c echo.Context) error
 }
@@ -27,25 +31,25 @@ type chatService struct {
 	config     *config.Config
 	llm        ollama.LLMService
 	repository *repository.Repository
-	client     *http.Client
+	exchanges  *postgres.ExchangeRepository
+	requests   *postgres.IdempotencyRepository
+	tools      *tools.Registry
+	answers    *cache.AnswerCache
+	grounding  *grounding.Checker
+	guard      *guardrails.Guard
 }
 
-func NewChatService(cfg *config.Config, repo *repository.Repository) (ChatService, error) {
-	llm, err := ollama.NewLLMService(cfg, repo)
-	if err != nil {
-		return nil, err
-	}
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	httpClient := &http.Client{Transport: tr}
-	httpClient.Timeout = cfg.Ollama.Timeout
-
+func NewChatService(cfg *config.Config, repo *repository.Repository, llm ollama.LLMService, exchanges *postgres.ExchangeRepository, requests *postgres.IdempotencyRepository, registry *tools.Registry, answers *cache.AnswerCache, checker *grounding.Checker, guard *guardrails.Guard) (ChatService, error) {
 	return &chatService{
 		config:     cfg,
 		repository: repo,
 		llm:        llm,
-		client:     httpClient,
+		exchanges:  exchanges,
+		requests:   requests,
+		tools:      registry,
+		answers:    answers,
+		grounding:  checker,
+		guard:      guard,
 	}, nil
 }
 
@@ -57,10 +61,10 @@ func NewChatService(cfg *config.Config, repo *repository.Repository) (ChatServic
 // @Accept json
 // @Produce	json
 // @Param		request	body		ChatRequest	true	"body param"
-// @Success	200				{object}		ChatRequest
+// @Success	200				{object}		ChatResponse
 // @Router /api/v1/chat 	[post]
 func (d *chatService) Chat(c echo.Context) error {
-	ctx := c.Request().Context()
+	ctx := ollama.WithPriority(c.Request().Context(), ollama.PriorityInteractive)
 	uid, ok := middleware.UserIDFromContext(c)
 	if !ok {
 		return c.JSON(http.StatusUnauthorized, "user not found")
@@ -71,39 +75,183 @@ func (d *chatService) Chat(c echo.Context) error {
 		return errors.NewBadRequestErrorRsp(err.Error())
 	}
 
-	embeddings, err := d.llm.Embed(SliceFromMesages(dataReq))
-	if err != nil {
-		return errors.NewInternalErrorRsp(err.Error())
+	if len(dataReq.Messages) == 0 || dataReq.Messages[len(dataReq.Messages)-1].Content == "" {
+		return errors.NewBadRequestErrorRsp("the last message must contain text")
 	}
-	response, err := d.llm.Answer(ctx, uid, embeddings[0][0], dataReq.Messages)
+	images, err := d.attachImages(&dataReq)
 	if err != nil {
-		return errors.NewInternalErrorRsp(err.Error())
+		return errors.NewBadRequestErrorRsp(err.Error())
+	}
+	// Reserve before any model call so that a replay costs nothing.
+	var claim *idempotency.Record
+	if dataReq.RqUID != "" {
+		claim, err = idempotency.NewRecord(idempotency.ScopeChat, uid, dataReq.RqUID, dataReq)
+		if err != nil {
+			return errors.NewInternalErrorRsp(err.Error())
+		}
+		prev, err := d.requests.Reserve(ctx, claim)
+		switch {
+		case stdErrors.Is(err, idempotency.ErrConflict), stdErrors.Is(err, idempotency.ErrInProgress):
+			return errors.NewConflictErrorRsp(err.Error())
+		case err != nil:
+			return errors.NewInternalErrorRsp(err.Error())
+		case prev != nil:
+			return c.JSONBlob(prev.StatusCode, prev.Response)
+		}
+		defer d.requests.Release(context.WithoutCancel(ctx), claim)
 	}
-	return c.JSON(http.StatusOK, response)
-}
 
-func SliceFromMesages(messages ChatRequest) []string {
-	var slice []string
-	slice = append(slice, messages.Messages[len(messages.Messages)-1].Content)
-	return slice
-}
+	if images > 0 {
+		vision, err := d.llm.SupportsVision(ctx)
+		if err != nil {
+			return errors.NewCustomErrorResponse(ollama.HTTPStatus(err), err.Error())
+		}
+		if !vision {
+			return errors.NewBadRequestErrorRsp("model " + d.llm.Models().Chat + " does not support images")
+		}
+	}
 
-func (d *chatService) handler(method string, url string, req any) ([]byte, int, error) {
-	body, err := json.Marshal(req)
+	if err := d.guard.CheckMessages(ctx, uid, dataReq.Messages); err != nil {
+		var blocked *guardrails.BlockedError
+		if stdErrors.As(err, &blocked) {
+			return errors.NewBadRequestErrorRsp(err.Error())
+		}
+		return errors.NewCustomErrorResponse(ollama.HTTPStatus(err), err.Error())
+	}
 
-	request, err := http.NewRequest(method, url, bytes.NewReader(body))
-	request.Header.Add("Content-Type", "application/json")
+	options := d.config.GenerationOptions(uid).Merge(dataReq.Options)
+	if err := options.Validate(); err != nil {
+		return errors.NewBadRequestErrorRsp(err.Error())
+	}
+	withOptions := ollama.WithOptions(&options)
 
-	res, err := d.client.Do(request)
-	if err != nil {
-		return nil, 0, err
+	var schema *jsonschema.Schema
+	if len(dataReq.Schema) > 0 {
+		if dataReq.UseTools {
+			return errors.NewBadRequestErrorRsp("schema cannot be combined with use_tools")
+		}
+		schema, err = jsonschema.Parse(dataReq.Schema)
+		if err != nil {
+			return errors.NewBadRequestErrorRsp(err.Error())
+		}
 	}
+	if dataReq.Stream && (dataReq.UseTools || schema != nil) {
+		return errors.NewBadRequestErrorRsp("stream cannot be combined with use_tools or schema")
+	}
+	verify := d.grounding.Enabled()
+	if dataReq.Verify != nil {
+		verify = *dataReq.Verify
+	}
+
+	var (
+		response *ollama.ChatResponse
+		trace    []tools.Invocation
+		data     any
+		cached   bool
+		report   *grounding.Report
+	)
+	if dataReq.UseTools {
+		response, trace, err = d.tools.Run(ctx, d.llm, uid, dataReq.Messages, d.config.Ollama.MaxToolRounds, withOptions)
+		if err != nil {
+			return errors.NewCustomErrorResponse(ollama.HTTPStatus(err), err.Error())
+		}
+	} else {
+		embeddings, err := d.llm.Embed(ctx, SliceFromMesages(dataReq))
+		if err != nil {
+			return errors.NewCustomErrorResponse(ollama.HTTPStatus(err), err.Error())
+		}
+		sources, err := d.llm.Search(ctx, uid, embeddings[0][0])
+		if err != nil {
+			return errors.NewInternalErrorRsp(err.Error())
+		}
+		sources, err = d.guard.Sources(ctx, uid, sources)
+		if err != nil {
+			return errors.NewCustomErrorResponse(ollama.HTTPStatus(err), err.Error())
+		}
+		in := answerInput{
+			embedding: embeddings[0][0],
+			sources:   sources,
+			messages:  dataReq.Messages,
+			options:   options,
+			cacheable: images == 0,
+			verify:    verify,
+		}
+		if dataReq.Stream {
+			return d.stream(ctx, c, uid, &dataReq, claim, in, withOptions)
+		}
+		if schema != nil {
+			response, data, err = d.answerStructured(ctx, uid, sources, dataReq.Messages, dataReq.Schema, schema, withOptions)
+		} else {
+			response, report, cached, err = d.answer(ctx, uid, in, withOptions)
+		}
+		var mismatch *schemaMismatchError
+		if stdErrors.As(err, &mismatch) {
+			return errors.NewUnprocessableErrorRsp(err.Error())
+		}
+		if err != nil {
+			return errors.NewCustomErrorResponse(ollama.HTTPStatus(err), err.Error())
+		}
+	}
+
+	result := ChatResponse{
+		RqUID:        dataReq.RqUID,
+		ToolTrace:    trace,
+		Data:         data,
+		Cached:       cached,
+		Grounding:    report,
+		ChatResponse: response,
+	}
+	d.finish(ctx, uid, &dataReq, claim, &result)
+	return c.JSON(http.StatusOK, result)
+}
 
-	defer res.Body.Close()
-	resBody, err := ioutil.ReadAll(res.Body)
+// finish adds follow-up questions when asked for, records the exchange and
+// stores the result for replays of the same request ID.
+func (d *chatService) finish(ctx context.Context, uid string, dataReq *ChatRequest, claim *idempotency.Record, result *ChatResponse) {
+	question := SliceFromMesages(*dataReq)[0]
+	if dataReq.FollowUps && (result.Grounding == nil || !result.Grounding.Blocked) {
+		followUps, err := d.followUps(ctx, question, result.Message.Content, result.Sources)
+		if err != nil {
+			log.Printf("suggest follow-up questions: %v", err)
+		}
+		result.FollowUps = followUps
+	}
+
+	record := exchange.Exchange{
+		MessageID: uuid.New().String(),
+		RqUID:     dataReq.RqUID,
+		UserID:    uid,
+		Question:  question,
+		Chunks:    result.Sources,
+		Answer:    result.Message.Content,
+		Model:     result.Model,
+	}
+	// The answer is already produced; keep it even if the client left.
+	bg := context.WithoutCancel(ctx)
+	if err := d.exchanges.Create(bg, &record); err != nil {
+		log.Printf("save chat exchange %s: %v", record.MessageID, err)
+	} else {
+		// Only a stored exchange can be rated.
+		result.MessageID = record.MessageID
+	}
+	if claim != nil {
+		if err := d.complete(bg, claim, http.StatusOK, *result); err != nil {
+			log.Printf("store chat result for request %s: %v", claim.RequestID, err)
+		}
+	}
+}
+
+func (d *chatService) complete(ctx context.Context, claim *idempotency.Record, status int, result any) error {
+	body, err := json.Marshal(result)
 	if err != nil {
-		return nil, 0, err
+		return err
 	}
+	claim.StatusCode, claim.Response = status, body
+	return d.requests.Complete(ctx, claim)
+}
 
-	return resBody, res.StatusCode, nil
+func SliceFromMesages(messages ChatRequest) []string {
+	var slice []string
+	slice = append(slice, messages.Messages[len(messages.Messages)-1].Content)
+	return slice
 }
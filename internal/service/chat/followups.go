@@ -0,0 +1,61 @@
+package chat
+
+import (
+	"ai-service/internal/service/ollama"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	minFollowUps = 3
+	maxFollowUps = 5
+)
+
+const followUpPrompt = "Предложи от 3 до 5 коротких вопросов, которые пользователь может задать дальше. " +
+	"Каждый вопрос должен иметь ответ во фрагментах документов и не повторять уже заданный вопрос. " +
+	"Пиши на языке пользователя. Ответь JSON вида {\"questions\": [\"...\"]}."
+
+var followUpSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"questions": {"type": "array", "items": {"type": "string"}, "minItems": 3, "maxItems": 5}
+	},
+	"required": ["questions"]
+}`)
+
+// followUps asks the model for questions the user could ask next, grounded
+// in the same sources as the answer.
+func (d *chatService) followUps(ctx context.Context, question, answer string, sources []ollama.Source) ([]string, error) {
+	var prompt strings.Builder
+	prompt.WriteString("Фрагменты документов:\n")
+	for i, source := range sources {
+		fmt.Fprintf(&prompt, "[%d] %s\n", i+1, source.Text)
+	}
+	fmt.Fprintf(&prompt, "\nВопрос пользователя: %s\nОтвет: %s\n", question, answer)
+
+	response, err := d.llm.Chat(ctx, []ollama.Message{
+		{Role: "system", Content: followUpPrompt},
+		{Role: "user", Content: prompt.String()},
+	}, ollama.WithFormat(followUpSchema))
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Questions []string `json:"questions"`
+	}
+	if err := json.Unmarshal([]byte(response.Message.Content), &result); err != nil {
+		return nil, fmt.Errorf("follow-up questions are not valid JSON: %w", err)
+	}
+	questions := make([]string, 0, maxFollowUps)
+	for _, q := range result.Questions {
+		if q = strings.TrimSpace(q); q != "" && len(questions) < maxFollowUps {
+			questions = append(questions, q)
+		}
+	}
+	if len(questions) < minFollowUps {
+		return nil, fmt.Errorf("model suggested %d follow-up questions, want at least %d", len(questions), minFollowUps)
+	}
+	return questions, nil
+}
@@ -0,0 +1,134 @@
+package chat
+
+import (
+	"ai-service/internal/service/cache"
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/config"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// streamLLM streams the scripted chunks and answers chat calls with reply.
+type streamLLM struct {
+	ollama.LLMService
+	chunks []ollama.ChatResponse
+	reply  string
+	calls  int
+}
+
+func (s *streamLLM) Models() ollama.ActiveModels {
+	return ollama.ActiveModels{Chat: "llama3"}
+}
+
+func (s *streamLLM) GenerateStream(_ context.Context, _ string, _ []ollama.Source, _ []ollama.Message, fn func(*ollama.ChatResponse) error, _ ...ollama.RequestOption) error {
+	s.calls++
+	for i := range s.chunks {
+		chunk := s.chunks[i]
+		if err := fn(&chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *streamLLM) Chat(_ context.Context, _ []ollama.Message, _ ...ollama.RequestOption) (*ollama.ChatResponse, error) {
+	return &ollama.ChatResponse{Message: ollama.Message{Role: "assistant", Content: s.reply}}, nil
+}
+
+func chunk(content string, done bool) ollama.ChatResponse {
+	return ollama.ChatResponse{Model: "llama3", Message: ollama.Message{Content: content}, Done: done}
+}
+
+func TestFollowUps(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		want  []string
+		err   string
+	}{
+		{"trimmed", `{"questions": [" Сколько стоит? ", "", "Кто подписал?", "Когда срок?"]}`, []string{"Сколько стоит?", "Кто подписал?", "Когда срок?"}, ""},
+		{"capped", `{"questions": ["1", "2", "3", "4", "5", "6"]}`, []string{"1", "2", "3", "4", "5"}, ""},
+		{"too few", `{"questions": ["1", " "]}`, nil, "suggested 1 follow-up questions"},
+		{"not JSON", "Вот вопросы", nil, "not valid JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &chatService{llm: &streamLLM{reply: tt.reply}}
+			got, err := d.followUps(context.Background(), "Вопрос", "Ответ", []ollama.Source{{Text: "фрагмент"}})
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnswerStreams(t *testing.T) {
+	llm := &streamLLM{chunks: []ollama.ChatResponse{chunk("Три ", false), chunk("года.", false), chunk("", true)}}
+	d := &chatService{llm: llm}
+	var streamed []string
+	in := answerInput{
+		messages: []ollama.Message{{Role: "user", Content: "Срок?"}},
+		onChunk: func(c *ollama.ChatResponse) error {
+			streamed = append(streamed, c.Message.Content)
+			return nil
+		},
+	}
+	response, _, cached, err := d.answer(context.Background(), "u1", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached || response.Message.Content != "Три года." || response.Message.Role != "assistant" || !response.Done {
+		t.Errorf("response %+v, cached %v", response, cached)
+	}
+	if !reflect.DeepEqual(streamed, []string{"Три ", "года."}) {
+		t.Errorf("streamed %q", streamed)
+	}
+}
+
+func TestAnswerStreamWithoutDone(t *testing.T) {
+	d := &chatService{llm: &streamLLM{chunks: []ollama.ChatResponse{chunk("Три", false)}}}
+	in := answerInput{
+		messages: []ollama.Message{{Role: "user", Content: "Срок?"}},
+		onChunk:  func(*ollama.ChatResponse) error { return nil },
+	}
+	if _, _, _, err := d.answer(context.Background(), "u1", in); err == nil || !strings.Contains(err.Error(), "ended before") {
+		t.Fatalf("got %v, want an unfinished stream error", err)
+	}
+}
+
+func TestAnswerStreamsCachedAnswer(t *testing.T) {
+	done := chunk("", true)
+	done.Sources = []ollama.Source{{DocumentID: "doc-a"}}
+	llm := &streamLLM{chunks: []ollama.ChatResponse{chunk("Три года.", false), done}}
+	d := &chatService{llm: llm, answers: cache.NewAnswerCache(config.AnswerCache{Enabled: true, Threshold: 0.9})}
+	var streamed []string
+	in := answerInput{
+		embedding: []float32{1, 0},
+		sources:   []ollama.Source{{DocumentID: "doc-a"}},
+		messages:  []ollama.Message{{Role: "user", Content: "Срок?"}},
+		cacheable: true,
+		onChunk: func(c *ollama.ChatResponse) error {
+			streamed = append(streamed, c.Message.Content)
+			return nil
+		},
+	}
+	for i := 0; i < 2; i++ {
+		if _, _, _, err := d.answer(context.Background(), "u1", in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if llm.calls != 1 {
+		t.Errorf("model called %d times, want once", llm.calls)
+	}
+	if !reflect.DeepEqual(streamed, []string{"Три года.", "Три года."}) {
+		t.Errorf("streamed %q, want the cached answer as one chunk", streamed)
+	}
+}
@@ -0,0 +1,65 @@
+package chat
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultMaxImageSize = 10 << 20
+	defaultMaxImages    = 4
+)
+
+var imageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/webp": true,
+}
+
+// attachImages moves request-level images onto the last message, strips
+// data-URL prefixes and checks count, size and type of every image.
+func (d *chatService) attachImages(req *ChatRequest) (int, error) {
+	if len(req.Images) > 0 {
+		last := &req.Messages[len(req.Messages)-1]
+		last.Images = append(last.Images, req.Images...)
+		req.Images = nil
+	}
+
+	maxSize, maxCount := d.config.Ollama.MaxImageSize, d.config.Ollama.MaxImages
+	if maxSize <= 0 {
+		maxSize = defaultMaxImageSize
+	}
+	if maxCount <= 0 {
+		maxCount = defaultMaxImages
+	}
+
+	count := 0
+	for i := range req.Messages {
+		for j, img := range req.Messages[i].Images {
+			count++
+			if count > maxCount {
+				return count, fmt.Errorf("at most %d images are allowed per request", maxCount)
+			}
+			if k := strings.Index(img, ";base64,"); strings.HasPrefix(img, "data:") && k >= 0 {
+				img = img[k+len(";base64,"):]
+				req.Messages[i].Images[j] = img
+			}
+			if base64.StdEncoding.DecodedLen(len(img)) > maxSize+2 {
+				return count, fmt.Errorf("image %d exceeds %d bytes", count, maxSize)
+			}
+			decoded, err := base64.StdEncoding.DecodeString(img)
+			if err != nil {
+				return count, fmt.Errorf("image %d is not valid base64", count)
+			}
+			if len(decoded) > maxSize {
+				return count, fmt.Errorf("image %d exceeds %d bytes", count, maxSize)
+			}
+			if mime := http.DetectContentType(decoded); !imageTypes[mime] {
+				return count, fmt.Errorf("image %d has unsupported type %s, accepted: png, jpeg, webp", count, mime)
+			}
+		}
+	}
+	return count, nil
+}
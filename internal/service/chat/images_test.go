@@ -0,0 +1,61 @@
+package chat
+
+import (
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/config"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+
+func imageService(maxSize, maxImages int) *chatService {
+	cfg := &config.Config{}
+	cfg.Ollama.MaxImageSize, cfg.Ollama.MaxImages = maxSize, maxImages
+	return &chatService{config: cfg}
+}
+
+func TestAttachImages(t *testing.T) {
+	png := base64.StdEncoding.EncodeToString(pngHeader)
+	req := ChatRequest{
+		Messages: []ollama.Message{{Role: "user", Content: "Раньше"}, {Role: "user", Content: "Что на картинке?", Images: []string{png}}},
+		Images:   []string{"data:image/png;base64," + png},
+	}
+	count, err := imageService(0, 0).attachImages(&req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 || req.Images != nil {
+		t.Fatalf("count %d, request images %v", count, req.Images)
+	}
+	last := req.Messages[1].Images
+	if len(last) != 2 || last[0] != png || last[1] != png {
+		t.Errorf("last message images %q, want both without the data URL prefix", last)
+	}
+}
+
+func TestAttachImagesRejects(t *testing.T) {
+	png := base64.StdEncoding.EncodeToString(pngHeader)
+	text := base64.StdEncoding.EncodeToString([]byte("просто текст"))
+	tests := []struct {
+		name    string
+		service *chatService
+		images  []string
+		err     string
+	}{
+		{"too many", imageService(0, 2), []string{png, png, png}, "at most 2 images"},
+		{"too large", imageService(len(pngHeader)-1, 0), []string{png}, "exceeds"},
+		{"not base64", imageService(0, 0), []string{"не base64!"}, "not valid base64"},
+		{"not an image", imageService(0, 0), []string{text}, "unsupported type text/plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := ChatRequest{Messages: []ollama.Message{{Role: "user", Content: "?"}}, Images: tt.images}
+			_, err := tt.service.attachImages(&req)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
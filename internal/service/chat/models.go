@@ -1,15 +1,44 @@
 package chat
 
-import "ai-service/internal/service/ollama"
+import (
+	"encoding/json"
+
+	"ai-service/internal/service/grounding"
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/service/tools"
+	"ai-service/internal/util/config"
+)
 
 type ChatRequest struct {
 	RqUID    string `json:"rquid"`
 	Messages []ollama.Message
+	// UseTools lets the model look documents up itself through the
+	// built-in tools instead of getting the top chunks in the prompt.
+	UseTools bool `json:"use_tools"`
+	// Schema switches the answer to JSON matching this JSON Schema.
+	Schema  json.RawMessage           `json:"schema,omitempty" swaggertype:"object"`
+	Options *config.GenerationOptions `json:"options,omitempty"`
+	// Images are base64 pictures attached to the last message.
+	Images []string `json:"images,omitempty"`
+	// Verify checks the answer against the retrieved chunks. Unset
+	// follows the grounding config.
+	Verify *bool `json:"verify,omitempty"`
+	// FollowUps adds 3-5 suggested next questions to the response.
+	FollowUps bool `json:"follow_ups,omitempty"`
+	// Stream sends the answer as newline-delimited JSON while it is
+	// generated; the last line is the complete ChatResponse.
+	Stream bool `json:"stream,omitempty"`
 }
 
 type ChatResponse struct {
-	Message struct {
-		Role    string `json:"role"`
-		Content string `json:"content"`
-	} `json:"message"`
+	MessageID string             `json:"message_id,omitempty"`
+	RqUID     string             `json:"rquid,omitempty"`
+	ToolTrace []tools.Invocation `json:"tool_trace,omitempty"`
+	// Data is the parsed answer when the request carried a schema.
+	Data any `json:"data,omitempty"`
+	// Cached is set when the answer came from the answer cache.
+	Cached    bool              `json:"cached,omitempty"`
+	Grounding *grounding.Report `json:"grounding,omitempty"`
+	FollowUps []string          `json:"follow_ups,omitempty"`
+	*ollama.ChatResponse
 }
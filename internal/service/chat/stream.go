@@ -0,0 +1,49 @@
+package chat
+
+import (
+	"ai-service/internal/service/idempotency"
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/errors"
+	"context"
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+)
+
+// stream writes partial answers as newline-delimited JSON and finishes
+// with the complete ChatResponse, done set. A failure after the first line
+// is sent as an error line, since the status is already out.
+func (d *chatService) stream(ctx context.Context, c echo.Context, uid string, dataReq *ChatRequest, claim *idempotency.Record, in answerInput, opts ...ollama.RequestOption) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(res)
+	write := func(v any) error {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		res.Flush()
+		return nil
+	}
+
+	in.onChunk = func(chunk *ollama.ChatResponse) error {
+		return write(chunk)
+	}
+	response, report, cached, err := d.answer(ctx, uid, in, opts...)
+	if err != nil {
+		return write(errors.ErrorResponse{
+			ErrorCode: strconv.Itoa(ollama.HTTPStatus(err)),
+			ErrorDesc: err.Error(),
+		})
+	}
+	result := ChatResponse{
+		RqUID:        dataReq.RqUID,
+		Cached:       cached,
+		Grounding:    report,
+		ChatResponse: response,
+	}
+	d.finish(ctx, uid, dataReq, claim, &result)
+	return write(result)
+}
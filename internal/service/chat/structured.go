@@ -0,0 +1,44 @@
+package chat
+
+import (
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/jsonschema"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const retryPrompt = "Твой предыдущий ответ не соответствует JSON Schema: %v. " +
+	"Ответь заново только JSON, который соответствует схеме."
+
+type schemaMismatchError struct {
+	attempts int
+	err      error
+}
+
+func (e *schemaMismatchError) Error() string {
+	return fmt.Sprintf("answer did not match the schema after %d attempts: %v", e.attempts, e.err)
+}
+
+// answerStructured asks the model for JSON constrained by schema and, when
+// the output still fails validation, re-asks with the validation error.
+func (d *chatService) answerStructured(ctx context.Context, uid string, sources []ollama.Source, messages []ollama.Message, raw json.RawMessage, schema *jsonschema.Schema, opts ...ollama.RequestOption) (*ollama.ChatResponse, any, error) {
+	attempts := 1 + max(d.config.Ollama.FormatRetries, 0)
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		response, err := d.llm.Generate(ctx, uid, sources, messages, append(opts, ollama.WithFormat(raw))...)
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := schema.Validate([]byte(response.Message.Content))
+		if err == nil {
+			return response, data, nil
+		}
+		lastErr = err
+		messages = append(messages[:len(messages):len(messages)],
+			ollama.Message{Role: "assistant", Content: response.Message.Content},
+			ollama.Message{Role: "user", Content: fmt.Sprintf(retryPrompt, err)},
+		)
+	}
+	return nil, nil, &schemaMismatchError{attempts: attempts, err: lastErr}
+}
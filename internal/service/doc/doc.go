@@ -1,26 +1,32 @@
 package doc
 
 import (
+	"ai-service/internal/repository/postgres"
 	"ai-service/internal/service/doc/models"
 	"ai-service/internal/service/document"
+	"ai-service/internal/service/idempotency"
 	"ai-service/internal/util/doc"
 	"ai-service/internal/util/errors"
 	"ai-service/internal/util/middleware"
-	"fmt"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	stdErrors "errors"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"log"
 	"net/http"
 )
 
 // SaveDoc
 //
-// @Description Save document
+// @Description Queue a document for ingestion. Its progress is reported by GET /upload/{id}
 // @Summary	Save document in milvus
 // @Tags doc
 // @Accept json
 // @Produce	json
 // @Param		request	body		models.SaveDocResponse	true	"body param"
-// @Success	200				{object}		models.SaveDocResponse
+// @Success	202				{object}		models.SaveDocResponse
 // @Router /api/v1/document 	[post]
 func (d *docService) SaveDoc(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -33,33 +39,75 @@ func (d *docService) SaveDoc(c echo.Context) error {
 		return errors.NewBadRequestErrorRsp(err.Error())
 	}
 
-	docID := uuid.New().String()
-	encodedString, err := doc.DecodeBase64ToFileAndRead(dataReq.Document)
-	if err != nil {
-		return errors.NewInternalErrorRsp(err.Error())
+	var claim *idempotency.Record
+	if dataReq.RequestID != "" {
+		var err error
+		claim, err = idempotency.NewRecord(idempotency.ScopeUpload, uid, dataReq.RequestID, dataReq)
+		if err != nil {
+			return errors.NewInternalErrorRsp(err.Error())
+		}
+		prev, err := d.requests.Reserve(ctx, claim)
+		switch {
+		case stdErrors.Is(err, idempotency.ErrConflict), stdErrors.Is(err, idempotency.ErrInProgress):
+			return errors.NewConflictErrorRsp(err.Error())
+		case err != nil:
+			return errors.NewInternalErrorRsp(err.Error())
+		case prev != nil:
+			return c.JSONBlob(prev.StatusCode, prev.Response)
+		}
+		defer d.requests.Release(context.WithoutCancel(ctx), claim)
 	}
-	embeddings, err := d.llm.Embed(encodedString)
+
+	data, err := base64.StdEncoding.DecodeString(dataReq.Document)
 	if err != nil {
-		return errors.NewInternalErrorRsp(err.Error())
+		return errors.NewBadRequestErrorRsp(err.Error())
 	}
-	err = d.repository.Vector.SaveDoc(ctx, uid, docID, encodedString, embeddings)
+	response, err := d.enqueue(ctx, document.Document{
+		UserID:       uid,
+		DocumentName: dataReq.Name,
+		Tags:         dataReq.Tags,
+		Priority:     dataReq.Priority,
+	}, data, dataReq.Name)
 	if err != nil {
-		fmt.Println(err)
-		return errors.NewInternalErrorRsp(err.Error())
+		return err
+	}
+	if claim != nil {
+		if err := d.complete(context.WithoutCancel(ctx), claim, http.StatusAccepted, response); err != nil {
+			log.Printf("store upload result for request %s: %v", claim.RequestID, err)
+		}
 	}
+	return c.JSON(http.StatusAccepted, response)
+}
 
-	pgModel := document.Document{
-		DocumentID:   docID,
-		UserID:       uid,
-		DocumentName: dataReq.Name,
+// enqueue stores a document as queued for the ingestion workers. Only the
+// format is checked here, so unsupported files are refused at once. Errors
+// are ready to be returned by a handler.
+func (d *docService) enqueue(ctx context.Context, pgModel document.Document, data []byte, filename string) (*models.SaveDocResponse, error) {
+	if _, err := doc.Detect(data, filename); err != nil {
+		return nil, errors.NewCustomErrorResponse(http.StatusUnsupportedMediaType, err.Error())
 	}
-	err = d.postgres.Create(ctx, &pgModel)
-	if err != nil {
-		return errors.NewInternalErrorRsp(err.Error())
+	pgModel.DocumentID = uuid.New().String()
+	job := document.Job{
+		DocumentID: pgModel.DocumentID,
+		UserID:     pgModel.UserID,
+		Filename:   filename,
+		Data:       data,
+		Priority:   pgModel.Priority,
 	}
+	if err := d.jobs.Enqueue(ctx, &pgModel, &job); err != nil {
+		return nil, errors.NewInternalErrorRsp(err.Error())
+	}
+	d.ingester.Wake()
+	return &models.SaveDocResponse{Status: true, DocumentID: pgModel.DocumentID, DocumentStatus: pgModel.Status}, nil
+}
 
-	response := models.SaveDocResponse{Status: true}
-	return c.JSON(http.StatusOK, response)
+func (d *docService) complete(ctx context.Context, claim *idempotency.Record, status int, result any) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	claim.StatusCode, claim.Response = status, body
+	return d.requests.Complete(ctx, claim)
 }
 
 // ListDoc
@@ -85,6 +133,38 @@ func (d *docService) ListDoc(c echo.Context) error {
 	return c.JSON(http.StatusOK, list)
 }
 
+// GetStatus
+//
+// @Description Ingestion status, progress and error of a document, with the extraction report once indexed
+// @Summary	Get document status
+// @Tags doc
+// @Produce	json
+// @Success	200				{object}		models.DocumentStatus
+// @Router /upload/{id} 	[get]
+func (d *docService) GetStatus(c echo.Context) error {
+	ctx := c.Request().Context()
+	uid, ok := middleware.UserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, "user not found")
+	}
+
+	record, err := d.postgres.GetByID(ctx, c.Param("id"))
+	if stdErrors.Is(err, postgres.ErrDocumentNotFound) || err == nil && record.UserID != uid {
+		return errors.NewNotFoundErrorRsp(postgres.ErrDocumentNotFound.Error())
+	}
+	if err != nil {
+		return errors.NewInternalErrorRsp(err.Error())
+	}
+	return c.JSON(http.StatusOK, models.DocumentStatus{
+		DocumentID: record.DocumentID,
+		Name:       record.DocumentName,
+		Status:     record.Status,
+		Progress:   record.Progress,
+		Error:      record.Error,
+		Report:     record.Report,
+	})
+}
+
 // DeleteDoc
 //
 // @Description Delete document
@@ -106,7 +186,18 @@ func (d *docService) DeleteDoc(c echo.Context) error {
 	if err := c.Bind(&dataReq); err != nil {
 		return errors.NewBadRequestErrorRsp(err.Error())
 	}
-	err := d.repository.Vector.DeleteDoc(ctx, uid, id)
+	record, err := d.postgres.GetByID(ctx, id)
+	if stdErrors.Is(err, postgres.ErrDocumentNotFound) || err == nil && record.UserID != uid {
+		return errors.NewNotFoundErrorRsp(postgres.ErrDocumentNotFound.Error())
+	}
+	if err != nil {
+		return errors.NewInternalErrorRsp(err.Error())
+	}
+	err = d.jobs.Delete(ctx, id)
+	if err != nil {
+		return errors.NewInternalErrorRsp(err.Error())
+	}
+	err = d.repository.Vector.DeleteDoc(ctx, uid, id)
 	if err != nil {
 		return errors.NewInternalErrorRsp(err.Error())
 	}
@@ -114,6 +205,7 @@ func (d *docService) DeleteDoc(c echo.Context) error {
 	if err != nil {
 		return errors.NewInternalErrorRsp(err.Error())
 	}
+	d.answers.InvalidateDocument(uid, id)
 	return c.JSON(http.StatusOK, nil)
 }
 
@@ -3,23 +3,17 @@ package doc
 import (
 	"ai-service/internal/repository"
 	"ai-service/internal/repository/postgres"
+	"ai-service/internal/service/cache"
+	"ai-service/internal/service/ingest"
 	"ai-service/internal/service/ollama"
 	"ai-service/internal/util/config"
-	"bytes"
-	"crypto/tls"
-	"encoding/json"
 	"github.com/labstack/echo/v4"
-	"io/ioutil"
-	"net/http"
-)
-
-const (
-	chat  = "/api/chat"
-	embed = "/api/embed"
 )
 
 type DocService interface {
 	SaveDoc(c echo.Context) error
+	UploadFiles(c echo.Context) error
+	GetStatus(c echo.Context) error
 	ListDoc(c echo.Context) error
 	DeleteDoc(c echo.Context) error
 	UpdatePriority(c echo.Context) error
@@ -30,45 +24,21 @@ type docService struct {
 	llm        ollama.LLMService
 	repository *repository.Repository
 	postgres   *postgres.DocumentRepository
-	client     *http.Client
+	requests   *postgres.IdempotencyRepository
+	answers    *cache.AnswerCache
+	jobs       *postgres.JobRepository
+	ingester   *ingest.Ingester
 }
 
-func NewDocService(cfg *config.Config, repo *repository.Repository, postgres *postgres.DocumentRepository) (DocService, error) {
-	llm, err := ollama.NewLLMService(cfg, repo)
-	if err != nil {
-		return nil, err
-	}
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	httpClient := &http.Client{Transport: tr}
-	httpClient.Timeout = cfg.Ollama.Timeout
-
+func NewDocService(cfg *config.Config, repo *repository.Repository, llm ollama.LLMService, postgres *postgres.DocumentRepository, requests *postgres.IdempotencyRepository, answers *cache.AnswerCache, jobs *postgres.JobRepository, ingester *ingest.Ingester) (DocService, error) {
 	return &docService{
 		config:     cfg,
 		repository: repo,
 		llm:        llm,
-		client:     httpClient,
 		postgres:   postgres,
+		requests:   requests,
+		answers:    answers,
+		jobs:       jobs,
+		ingester:   ingester,
 	}, nil
 }
-
-func (d *docService) handler(method string, url string, req any) ([]byte, int, error) {
-	body, err := json.Marshal(req)
-
-	request, err := http.NewRequest(method, url, bytes.NewReader(body))
-	request.Header.Add("Content-Type", "application/json")
-
-	res, err := d.client.Do(request)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	defer res.Body.Close()
-	resBody, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	return resBody, res.StatusCode, nil
-}
@@ -1,14 +1,45 @@
 package models
 
+import (
+	"ai-service/internal/service/document"
+	"ai-service/internal/util/errors"
+)
+
 type SaveDoc struct {
-	ID        string `json:"id"`
-	RequestID string `json:"request_id"`
-	Document  string `json:"document"`
-	Name      string `json:"name"`
-	CompanyId string `json:"company_id"`
-	Priority  bool   `json:"priority"`
+	ID        string   `json:"id"`
+	RequestID string   `json:"request_id"`
+	Document  string   `json:"document"`
+	Name      string   `json:"name"`
+	CompanyId string   `json:"company_id"`
+	Tags      []string `json:"tags"`
+	Priority  bool     `json:"priority"`
 }
 
 type SaveDocResponse struct {
-	Status bool `json:"status"`
+	Status         bool   `json:"status"`
+	DocumentID     string `json:"document_id,omitempty"`
+	DocumentStatus string `json:"document_status,omitempty"`
+}
+
+// DocumentStatus is how far the ingestion of a document got. Progress is
+// a percentage; Report is set once the document is indexed.
+type DocumentStatus struct {
+	DocumentID string           `json:"document_id"`
+	Name       string           `json:"name"`
+	Status     string           `json:"status"`
+	Progress   int              `json:"progress"`
+	Error      string           `json:"error,omitempty"`
+	Report     *document.Report `json:"report,omitempty"`
+}
+
+// UploadResponse reports every file of a multipart upload on its own, so
+// one bad file does not fail the others.
+type UploadResponse struct {
+	Files []UploadFileResponse `json:"files"`
+}
+
+type UploadFileResponse struct {
+	Filename string `json:"filename"`
+	SaveDocResponse
+	Error *errors.ErrorResponse `json:"error,omitempty"`
 }
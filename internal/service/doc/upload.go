@@ -0,0 +1,180 @@
+package doc
+
+import (
+	"ai-service/internal/service/doc/models"
+	"ai-service/internal/service/document"
+	"ai-service/internal/util/errors"
+	"ai-service/internal/util/middleware"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxFieldSize bounds the plain form fields of an upload.
+const maxFieldSize = 64 << 10
+
+// spooledFile is a file part copied to disk while the request is read.
+type spooledFile struct {
+	filename string
+	path     string
+}
+
+// UploadFiles
+//
+// @Description Upload documents as multipart/form-data. Every file is queued on its own and reported in the response. A file larger than upload.max_file_size (50 MiB by default, at most 256 MiB) is rejected with 413
+// @Summary	Upload files in milvus
+// @Tags doc
+// @Accept mpfd
+// @Produce	json
+// @Param		file		formData	file	true	"document, repeat the field for several files"
+// @Param		name		formData	string	false	"document name, only with a single file"
+// @Param		tags		formData	string	false	"tags, repeat the field or separate with commas"
+// @Param		priority	formData	bool	false	"priority"
+// @Success	202				{object}		models.UploadResponse
+// @Router /upload/files 	[post]
+func (d *docService) UploadFiles(c echo.Context) error {
+	ctx := c.Request().Context()
+	uid, ok := middleware.UserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, "user not found")
+	}
+	reader, err := c.Request().MultipartReader()
+	if err != nil {
+		return errors.NewBadRequestErrorRsp(err.Error())
+	}
+
+	var files []spooledFile
+	defer func() {
+		for _, f := range files {
+			os.Remove(f.path)
+		}
+	}()
+	form := url.Values{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.NewBadRequestErrorRsp(err.Error())
+		}
+		if part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, maxFieldSize+1))
+			if err != nil {
+				return errors.NewBadRequestErrorRsp(err.Error())
+			}
+			if len(value) > maxFieldSize {
+				return errors.NewBadRequestErrorRsp(fmt.Sprintf("field %s exceeds %d bytes", part.FormName(), maxFieldSize))
+			}
+			form.Add(part.FormName(), string(value))
+			continue
+		}
+		if len(files) == d.config.Upload.MaxFiles {
+			return errors.NewBadRequestErrorRsp(fmt.Sprintf("at most %d files are allowed per request", d.config.Upload.MaxFiles))
+		}
+		file, err := d.spool(part)
+		if file.path != "" {
+			files = append(files, file)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(files) == 0 {
+		return errors.NewBadRequestErrorRsp("no files in the request")
+	}
+	name := strings.TrimSpace(form.Get("name"))
+	if name != "" && len(files) > 1 {
+		return errors.NewBadRequestErrorRsp("name can only be set when uploading a single file")
+	}
+	priority := false
+	if value := form.Get("priority"); value != "" {
+		priority, err = strconv.ParseBool(value)
+		if err != nil {
+			return errors.NewBadRequestErrorRsp("priority must be true or false")
+		}
+	}
+	tags := parseTags(form["tags"])
+
+	response := models.UploadResponse{Files: make([]models.UploadFileResponse, 0, len(files))}
+	for _, f := range files {
+		result := models.UploadFileResponse{Filename: f.filename}
+		pgModel := document.Document{UserID: uid, DocumentName: name, Tags: tags, Priority: priority}
+		if pgModel.DocumentName == "" {
+			pgModel.DocumentName = f.filename
+		}
+		// The job stores the file whole; spool capped it at
+		// Upload.MaxFileSize, and one file is in memory at a time.
+		data, err := os.ReadFile(f.path)
+		if err == nil {
+			var queued *models.SaveDocResponse
+			queued, err = d.enqueue(ctx, pgModel, data, f.filename)
+			if queued != nil {
+				result.SaveDocResponse = *queued
+			}
+		}
+		if err != nil {
+			result.Error = errorResponse(err)
+		}
+		response.Files = append(response.Files, result)
+	}
+	return c.JSON(http.StatusAccepted, response)
+}
+
+// spool copies a file part to a temporary file and fails once the part
+// grows past the configured size. The file is returned even on failure so
+// the caller can remove it.
+func (d *docService) spool(part *multipart.Part) (spooledFile, error) {
+	limit := d.config.Upload.MaxFileSize
+	file, err := os.CreateTemp(d.config.Upload.TempDir, "upload-*")
+	if err != nil {
+		return spooledFile{}, errors.NewInternalErrorRsp(err.Error())
+	}
+	defer file.Close()
+
+	spooled := spooledFile{filename: part.FileName(), path: file.Name()}
+	n, err := io.Copy(file, io.LimitReader(part, limit+1))
+	if err != nil {
+		return spooled, errors.NewBadRequestErrorRsp(err.Error())
+	}
+	if n > limit {
+		return spooled, errors.NewCustomErrorResponse(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("file %s exceeds %d bytes", spooled.filename, limit))
+	}
+	return spooled, nil
+}
+
+// parseTags accepts tags both as repeated fields and comma-separated.
+func parseTags(values []string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag != "" && !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+func errorResponse(err error) *errors.ErrorResponse {
+	var httpErr *echo.HTTPError
+	if stdErrors.As(err, &httpErr) {
+		if rsp, ok := httpErr.Message.(errors.ErrorResponse); ok {
+			return &rsp
+		}
+	}
+	return &errors.ErrorResponse{ErrorCode: strconv.Itoa(http.StatusInternalServerError), ErrorDesc: err.Error()}
+}
@@ -0,0 +1,136 @@
+package doc
+
+import (
+	"ai-service/internal/util/config"
+	"ai-service/internal/util/errors"
+	"ai-service/internal/util/middleware"
+	"bytes"
+	stdErrors "errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+type formPart struct {
+	field, filename, content string
+}
+
+// upload posts parts as multipart/form-data to UploadFiles with uploads
+// spooled into a fresh directory, which is returned.
+func upload(t *testing.T, upload config.Upload, parts ...formPart) (string, error) {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, p := range parts {
+		var err error
+		if p.filename != "" {
+			var f io.Writer
+			f, err = w.CreateFormFile(p.field, p.filename)
+			if err == nil {
+				_, err = f.Write([]byte(p.content))
+			}
+		} else {
+			err = w.WriteField(p.field, p.content)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	upload.TempDir = t.TempDir()
+	d := &docService{config: &config.Config{Upload: upload}}
+	req := httptest.NewRequest(http.MethodPost, "/upload/files", &body)
+	req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+	c.Set(middleware.UserIDContextKey, "u1")
+	return upload.TempDir, d.UploadFiles(c)
+}
+
+func TestUploadFilesRejects(t *testing.T) {
+	limits := config.Upload{MaxFileSize: 10, MaxFiles: 2}
+	file := func(name, content string) formPart { return formPart{"file", name, content} }
+	tests := []struct {
+		name   string
+		parts  []formPart
+		status int
+		desc   string
+	}{
+		{"no files", []formPart{{"name", "", "Договор"}}, http.StatusBadRequest, "no files in the request"},
+		{"too many files", []formPart{file("a.txt", "a"), file("b.txt", "b"), file("c.txt", "c")}, http.StatusBadRequest, "at most 2 files"},
+		{"file too large", []formPart{file("a.txt", "a"), file("big.txt", "0123456789A")}, http.StatusRequestEntityTooLarge, "file big.txt exceeds 10 bytes"},
+		{"field too large", []formPart{{"tags", "", strings.Repeat("t", maxFieldSize+1)}}, http.StatusBadRequest, "field tags exceeds"},
+		{"name for several files", []formPart{{"name", "", "Договор"}, file("a.txt", "a"), file("b.txt", "b")}, http.StatusBadRequest, "name can only be set"},
+		{"bad priority", []formPart{{"priority", "", "срочно"}, file("a.txt", "a")}, http.StatusBadRequest, "priority must be true or false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := upload(t, limits, tt.parts...)
+			var httpErr *echo.HTTPError
+			if !stdErrors.As(err, &httpErr) || httpErr.Code != tt.status {
+				t.Fatalf("got %v, want status %d", err, tt.status)
+			}
+			if rsp := errorResponse(err); !strings.Contains(rsp.ErrorDesc, tt.desc) {
+				t.Errorf("error %q, want %q", rsp.ErrorDesc, tt.desc)
+			}
+			if left, _ := os.ReadDir(dir); len(left) != 0 {
+				t.Errorf("%d spooled files were left behind", len(left))
+			}
+		})
+	}
+}
+
+func TestSpoolKeepsFileWithinLimit(t *testing.T) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	f, err := w.CreateFormFile("file", "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(f, "0123456789"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &docService{config: &config.Config{Upload: config.Upload{MaxFileSize: 10, TempDir: t.TempDir()}}}
+	part, err := multipart.NewReader(&body, w.Boundary()).NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spooled, err := d.spool(part)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(spooled.path)
+	if err != nil || string(data) != "0123456789" || spooled.filename != "a.txt" {
+		t.Errorf("spooled %+v with %q, %v", spooled, data, err)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	got := parseTags([]string{"договор, 2024", " счёт ", "договор,,"})
+	want := []string{"договор", "2024", "счёт"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTags() = %q, want %q", got, want)
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	if rsp := errorResponse(errors.NewCustomErrorResponse(http.StatusConflict, "занято")); rsp.ErrorCode != "409" || rsp.ErrorDesc != "занято" {
+		t.Errorf("HTTP error became %+v", rsp)
+	}
+	if rsp := errorResponse(stdErrors.New("сбой")); rsp.ErrorCode != "500" || rsp.ErrorDesc != "сбой" {
+		t.Errorf("plain error became %+v", rsp)
+	}
+}
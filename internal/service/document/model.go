@@ -1,7 +1,73 @@
 package document
 
+import "time"
+
+// Ingestion statuses of a document, in the order they are passed.
+const (
+	StatusQueued     = "queued"
+	StatusExtracting = "extracting"
+	StatusEmbedding  = "embedding"
+	StatusIndexed    = "indexed"
+	StatusFailed     = "failed"
+)
+
+// Document is an uploaded document. Status and Progress, a percentage,
+// follow its ingestion; Error says why it failed and Report how the text
+// was extracted.
 type Document struct {
-	UserID       string `db:"user_id"`
-	DocumentID   string `db:"document_id"`
-	DocumentName string `db:"document_name"`
+	UserID       string   `db:"user_id"`
+	DocumentID   string   `db:"document_id"`
+	DocumentName string   `db:"document_name"`
+	Tags         []string `db:"tags"`
+	Priority     bool     `db:"priority"`
+	Status       string   `db:"status"`
+	Progress     int      `db:"progress"`
+	Error        string   `db:"error"`
+	Report       *Report  `db:"report"`
+}
+
+// Job is a queued ingestion of a document. Attempts counts the claims,
+// including the current one.
+type Job struct {
+	DocumentID string `db:"document_id"`
+	UserID     string `db:"user_id"`
+	Filename   string `db:"filename"`
+	Data       []byte `db:"data"`
+	Priority   bool   `db:"priority"`
+	Attempts   int    `db:"attempts"`
+}
+
+// Chunk is a piece of a document sized for embedding. Start and End are
+// character offsets into the extracted text; Page is 1-based, 0 when the
+// format has no pages. Section is the heading path the chunk belongs to.
+type Chunk struct {
+	Text    string `json:"text"`
+	Index   int    `json:"chunk_index"`
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	Page    int    `json:"page,omitempty"`
+	Section string `json:"section,omitempty"`
+}
+
+// Metadata is what a document says about itself.
+type Metadata struct {
+	Title   string     `json:"title,omitempty"`
+	Author  string     `json:"author,omitempty"`
+	Created *time.Time `json:"created,omitempty"`
+}
+
+// Report describes how the text of a document was extracted. Pages are
+// 1-based; ImageOnlyPages had no text layer, only pictures, and OCRPages
+// were read from their pictures.
+type Report struct {
+	Metadata
+	Pages          int         `json:"pages,omitempty"`
+	FailedPages    []PageError `json:"failed_pages,omitempty"`
+	ImageOnlyPages []int       `json:"image_only_pages,omitempty"`
+	OCRPages       []int       `json:"ocr_pages,omitempty"`
+}
+
+type PageError struct {
+	Page  int    `json:"page"`
+	Error string `json:"error"`
 }
@@ -0,0 +1,41 @@
+package exchange
+
+import (
+	"time"
+
+	"ai-service/internal/service/ollama"
+)
+
+const (
+	RatingUp   = 1
+	RatingDown = -1
+)
+
+// Exchange is a single question/answer turn persisted together with the
+// chunks that were retrieved to produce the answer.
+type Exchange struct {
+	MessageID string          `db:"message_id" json:"message_id"`
+	RqUID     string          `db:"rquid" json:"rquid,omitempty"`
+	UserID    string          `db:"user_id" json:"user_id"`
+	Question  string          `db:"question" json:"question"`
+	Chunks    []ollama.Source `db:"chunks" json:"chunks"`
+	Answer    string          `db:"answer" json:"answer"`
+	Model     string          `db:"model" json:"model"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+}
+
+type Feedback struct {
+	FeedbackID  string    `db:"feedback_id" json:"feedback_id"`
+	MessageID   string    `db:"message_id" json:"message_id"`
+	UserID      string    `db:"user_id" json:"user_id"`
+	Rating      int       `db:"rating" json:"rating"`
+	Comment     string    `db:"comment" json:"comment,omitempty"`
+	WrongSource bool      `db:"wrong_source" json:"wrong_source"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+// Review is a feedback entry joined with the exchange it rates.
+type Review struct {
+	Feedback
+	Exchange Exchange `json:"exchange"`
+}
@@ -0,0 +1,25 @@
+package feedback
+
+import (
+	"ai-service/internal/repository/postgres"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+type FeedbackService interface {
+	Rate(c echo.Context) error
+	ListNegative(c echo.Context) error
+	ExportNegative(c echo.Context) error
+}
+
+type feedbackService struct {
+	exchanges *postgres.ExchangeRepository
+}
+
+func NewFeedbackService(exchanges *postgres.ExchangeRepository) FeedbackService {
+	return &feedbackService{exchanges: exchanges}
+}
@@ -0,0 +1,150 @@
+package feedback
+
+import (
+	"ai-service/internal/repository/postgres"
+	"ai-service/internal/service/exchange"
+	"ai-service/internal/util/errors"
+	"ai-service/internal/util/middleware"
+	"encoding/json"
+	stdErrors "errors"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+)
+
+// Rate
+//
+// @Description Rate a chat answer
+// @Summary	Thumbs up/down for a chat answer
+// @Tags chat
+// @Accept json
+// @Produce	json
+// @Param		request	body		RateRequest	true	"body param"
+// @Success	200				{object}		RateResponse
+// @Router /api/v1/chat/feedback 	[post]
+func (f *feedbackService) Rate(c echo.Context) error {
+	ctx := c.Request().Context()
+	uid, ok := middleware.UserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, "user not found")
+	}
+
+	var dataReq RateRequest
+	if err := c.Bind(&dataReq); err != nil {
+		return errors.NewBadRequestErrorRsp(err.Error())
+	}
+	if err := c.Validate(&dataReq); err != nil {
+		return errors.NewBadRequestErrorRsp(err.Error())
+	}
+
+	var (
+		record *exchange.Exchange
+		err    error
+	)
+	if dataReq.MessageID != "" {
+		record, err = f.exchanges.GetByID(ctx, uid, dataReq.MessageID)
+	} else {
+		record, err = f.exchanges.GetByRqUID(ctx, uid, dataReq.RqUID)
+	}
+	if stdErrors.Is(err, postgres.ErrExchangeNotFound) {
+		return errors.NewNotFoundErrorRsp(err.Error())
+	}
+	if err != nil {
+		return errors.NewInternalErrorRsp(err.Error())
+	}
+
+	rating := exchange.RatingUp
+	if dataReq.Rating == "down" {
+		rating = exchange.RatingDown
+	}
+	fb := exchange.Feedback{
+		FeedbackID:  uuid.New().String(),
+		MessageID:   record.MessageID,
+		UserID:      uid,
+		Rating:      rating,
+		Comment:     dataReq.Comment,
+		WrongSource: dataReq.WrongSource,
+	}
+	if err := f.exchanges.SaveFeedback(ctx, &fb); err != nil {
+		return errors.NewInternalErrorRsp(err.Error())
+	}
+	return c.JSON(http.StatusOK, RateResponse{FeedbackID: fb.FeedbackID, MessageID: fb.MessageID})
+}
+
+// ListNegative
+//
+// @Description List negative feedback
+// @Summary	List thumbs-down and wrong-source feedback with the rated answers
+// @Tags admin
+// @Produce	json
+// @Param		limit	query	int	false	"page size"
+// @Param		offset	query	int	false	"page offset"
+// @Success	200				{array}		exchange.Review
+// @Router /api/v1/admin/feedback 	[get]
+func (f *feedbackService) ListNegative(c echo.Context) error {
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return errors.NewBadRequestErrorRsp(err.Error())
+	}
+	reviews, err := f.exchanges.ListNegative(c.Request().Context(), limit, offset)
+	if err != nil {
+		return errors.NewInternalErrorRsp(err.Error())
+	}
+	return c.JSON(http.StatusOK, reviews)
+}
+
+// ExportNegative
+//
+// @Description Export negative feedback
+// @Summary	Download negative feedback as JSON Lines for curation
+// @Tags admin
+// @Produce	application/x-ndjson
+// @Param		limit	query	int	false	"max records"
+// @Param		offset	query	int	false	"offset"
+// @Success	200
+// @Router /api/v1/admin/feedback/export 	[get]
+func (f *feedbackService) ExportNegative(c echo.Context) error {
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return errors.NewBadRequestErrorRsp(err.Error())
+	}
+	if c.QueryParam("limit") == "" {
+		limit = maxLimit
+	}
+	reviews, err := f.exchanges.ListNegative(c.Request().Context(), limit, offset)
+	if err != nil {
+		return errors.NewInternalErrorRsp(err.Error())
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="negative_feedback.jsonl"`)
+	res.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(res)
+	for _, review := range reviews {
+		if err := enc.Encode(review); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pagination(c echo.Context) (int, int, error) {
+	limit, offset := defaultLimit, 0
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxLimit {
+			return 0, 0, stdErrors.New("limit must be between 1 and " + strconv.Itoa(maxLimit))
+		}
+		limit = n
+	}
+	if v := c.QueryParam("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, 0, stdErrors.New("offset must be a non-negative integer")
+		}
+		offset = n
+	}
+	return limit, offset, nil
+}
@@ -0,0 +1,14 @@
+package feedback
+
+type RateRequest struct {
+	MessageID   string `json:"message_id" validate:"required_without=RqUID,omitempty,uuid"`
+	RqUID       string `json:"rquid" validate:"required_without=MessageID"`
+	Rating      string `json:"rating" validate:"required,oneof=up down"`
+	Comment     string `json:"comment" validate:"max=2000"`
+	WrongSource bool   `json:"wrong_source"`
+}
+
+type RateResponse struct {
+	FeedbackID string `json:"feedback_id"`
+	MessageID  string `json:"message_id"`
+}
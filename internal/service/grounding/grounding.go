@@ -0,0 +1,150 @@
+package grounding
+
+import (
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const judgePrompt = "Ты проверяешь, подтверждается ли ответ документами. " +
+	"Для каждого пронумерованного предложения ответа реши, следует ли оно из фрагментов документов. " +
+	"Предложение подтверждено, только если его утверждения прямо содержатся во фрагментах. " +
+	"Ответь JSON вида {\"verdicts\": [{\"sentence\": 1, \"supported\": true}]}."
+
+const defaultBlockMessage = "Не удалось подтвердить ответ документами. Попробуйте переформулировать вопрос."
+
+var verdictSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"verdicts": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"sentence": {"type": "integer"},
+					"supported": {"type": "boolean"}
+				},
+				"required": ["sentence", "supported"]
+			}
+		}
+	},
+	"required": ["verdicts"]
+}`)
+
+var sentenceEnd = regexp.MustCompile(`[.!?…]+["»)\]]*\s+|\n+`)
+
+// Checker asks a judge model which sentences of an answer are backed by
+// the retrieved chunks.
+type Checker struct {
+	config config.Grounding
+	llm    ollama.LLMService
+}
+
+func NewChecker(cfg config.Grounding, llm ollama.LLMService) *Checker {
+	return &Checker{config: cfg, llm: llm}
+}
+
+// Enabled reports whether answers are checked unless the request asks
+// otherwise.
+func (c *Checker) Enabled() bool {
+	return c != nil && c.config.Enabled
+}
+
+// Check scores answer against sources. With the block action, a failing
+// answer is marked Blocked and the caller should not show it.
+func (c *Checker) Check(ctx context.Context, answer string, sources []ollama.Source) (*Report, error) {
+	sentences := splitSentences(answer)
+	if len(sentences) == 0 {
+		return &Report{Score: 1, Passed: true}, nil
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Фрагменты документов:\n")
+	for i, source := range sources {
+		fmt.Fprintf(&prompt, "[%d] %s\n", i+1, source.Text)
+	}
+	prompt.WriteString("\nПредложения ответа:\n")
+	for i, sentence := range sentences {
+		fmt.Fprintf(&prompt, "%d. %s\n", i+1, sentence.Text)
+	}
+
+	temperature := 0.0
+	opts := []ollama.RequestOption{
+		ollama.WithFormat(verdictSchema),
+		ollama.WithOptions(&config.GenerationOptions{Temperature: &temperature}),
+	}
+	if c.config.Model != "" {
+		opts = append(opts, ollama.WithModel(c.config.Model))
+	}
+	response, err := c.llm.Chat(ctx, []ollama.Message{
+		{Role: "system", Content: judgePrompt},
+		{Role: "user", Content: prompt.String()},
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var result verdicts
+	if err := json.Unmarshal([]byte(response.Message.Content), &result); err != nil {
+		return nil, fmt.Errorf("judge returned invalid verdicts: %w", err)
+	}
+
+	// Sentences the judge did not rule on count as unsupported.
+	supported := make([]bool, len(sentences))
+	for _, v := range result.Verdicts {
+		if v.Sentence >= 1 && v.Sentence <= len(sentences) {
+			supported[v.Sentence-1] = v.Supported
+		}
+	}
+	report := &Report{}
+	count := 0
+	for i, ok := range supported {
+		if ok {
+			count++
+		} else {
+			report.Unsupported = append(report.Unsupported, sentences[i])
+		}
+	}
+	report.Score = float64(count) / float64(len(sentences))
+	report.Passed = report.Score >= c.config.Threshold
+	report.Blocked = !report.Passed && c.config.Action == ActionBlock
+	return report, nil
+}
+
+// BlockMessage is shown instead of a blocked answer.
+func (c *Checker) BlockMessage() string {
+	if c.config.BlockMessage != "" {
+		return c.config.BlockMessage
+	}
+	return defaultBlockMessage
+}
+
+// splitSentences cuts text at sentence punctuation and line breaks, leaving
+// out pieces without letters or digits such as list markers.
+func splitSentences(text string) []Span {
+	var spans []Span
+	add := func(from, to int) {
+		piece := text[from:to]
+		trimmed := strings.TrimSpace(piece)
+		if strings.IndexFunc(trimmed, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			return
+		}
+		from += strings.Index(piece, trimmed)
+		start := utf8.RuneCountInString(text[:from])
+		spans = append(spans, Span{Text: trimmed, Start: start, End: start + utf8.RuneCountInString(trimmed)})
+	}
+	from := 0
+	for _, loc := range sentenceEnd.FindAllStringIndex(text, -1) {
+		add(from, loc[1])
+		from = loc[1]
+	}
+	if from < len(text) {
+		add(from, len(text))
+	}
+	return spans
+}
@@ -0,0 +1,143 @@
+package grounding
+
+import (
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/config"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// judgeLLM answers every chat call with reply and records the request.
+type judgeLLM struct {
+	ollama.LLMService
+	reply string
+	err   error
+	calls []ollama.ChatRequest
+}
+
+func (j *judgeLLM) Chat(_ context.Context, messages []ollama.Message, opts ...ollama.RequestOption) (*ollama.ChatResponse, error) {
+	req := ollama.ChatRequest{Messages: messages}
+	for _, opt := range opts {
+		opt(&req)
+	}
+	j.calls = append(j.calls, req)
+	if j.err != nil {
+		return nil, j.err
+	}
+	return &ollama.ChatResponse{Message: ollama.Message{Role: "assistant", Content: j.reply}}, nil
+}
+
+func TestSplitSentences(t *testing.T) {
+	text := "Срок — 3 года. Оплата ежемесячно!\n- \nШтраф 5%?"
+	want := []Span{
+		{Text: "Срок — 3 года.", Start: 0, End: 14},
+		{Text: "Оплата ежемесячно!", Start: 15, End: 33},
+		{Text: "Штраф 5%?", Start: 37, End: 46},
+	}
+	if got := splitSentences(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitSentences() = %+v, want %+v", got, want)
+	}
+	for _, span := range want {
+		if got := string([]rune(text)[span.Start:span.End]); got != span.Text {
+			t.Errorf("offsets %d:%d give %q, want %q", span.Start, span.End, got, span.Text)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	answer := "Срок договора три года. Оплата ежемесячно. Штраф не предусмотрен."
+	tests := []struct {
+		name        string
+		config      config.Grounding
+		reply       string
+		score       float64
+		passed      bool
+		blocked     bool
+		unsupported int
+	}{
+		{
+			name:   "all supported",
+			config: config.Grounding{Threshold: 0.8, Action: ActionBlock},
+			reply:  `{"verdicts": [{"sentence": 1, "supported": true}, {"sentence": 2, "supported": true}, {"sentence": 3, "supported": true}]}`,
+			score:  1, passed: true,
+		},
+		{
+			name:   "flagged",
+			config: config.Grounding{Threshold: 0.8, Action: ActionFlag},
+			reply:  `{"verdicts": [{"sentence": 1, "supported": true}, {"sentence": 2, "supported": true}, {"sentence": 3, "supported": false}]}`,
+			score:  2.0 / 3, unsupported: 1,
+		},
+		{
+			name:   "blocked",
+			config: config.Grounding{Threshold: 0.8, Action: ActionBlock},
+			reply:  `{"verdicts": [{"sentence": 1, "supported": true}, {"sentence": 2, "supported": true}, {"sentence": 3, "supported": false}]}`,
+			score:  2.0 / 3, blocked: true, unsupported: 1,
+		},
+		{
+			name:   "missing and out of range verdicts",
+			config: config.Grounding{Threshold: 0.3, Action: ActionBlock},
+			reply:  `{"verdicts": [{"sentence": 2, "supported": true}, {"sentence": 7, "supported": true}]}`,
+			score:  1.0 / 3, passed: true, unsupported: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(tt.config, &judgeLLM{reply: tt.reply})
+			report, err := c.Check(context.Background(), answer, []ollama.Source{{Text: "Договор заключён на три года."}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Score != tt.score || report.Passed != tt.passed || report.Blocked != tt.blocked || len(report.Unsupported) != tt.unsupported {
+				t.Errorf("report %+v", report)
+			}
+		})
+	}
+}
+
+func TestCheckRequest(t *testing.T) {
+	llm := &judgeLLM{reply: `{"verdicts": []}`}
+	c := NewChecker(config.Grounding{Model: "judge"}, llm)
+	if _, err := c.Check(context.Background(), "Один. Два.", []ollama.Source{{Text: "фрагмент"}}); err != nil {
+		t.Fatal(err)
+	}
+	req := llm.calls[0]
+	if req.Model != "judge" || req.Format == nil || req.Options == nil || *req.Options.Temperature != 0 {
+		t.Errorf("request model %q, format %s, options %+v", req.Model, req.Format, req.Options)
+	}
+	prompt := req.Messages[1].Content
+	for _, want := range []string{"[1] фрагмент", "1. Один.", "2. Два."} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt %q lacks %q", prompt, want)
+		}
+	}
+}
+
+func TestCheckWithoutSentences(t *testing.T) {
+	llm := &judgeLLM{}
+	report, err := NewChecker(config.Grounding{Threshold: 1}, llm).Check(context.Background(), " - \n", nil)
+	if err != nil || !report.Passed || len(llm.calls) != 0 {
+		t.Errorf("report %+v, error %v, judge calls %d", report, err, len(llm.calls))
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	failure := errors.New("judge is down")
+	if _, err := NewChecker(config.Grounding{}, &judgeLLM{err: failure}).Check(context.Background(), "Ответ.", nil); !errors.Is(err, failure) {
+		t.Errorf("got %v, want the judge error", err)
+	}
+	if _, err := NewChecker(config.Grounding{}, &judgeLLM{reply: "да"}).Check(context.Background(), "Ответ.", nil); err == nil || !strings.Contains(err.Error(), "invalid verdicts") {
+		t.Errorf("got %v, want an invalid verdicts error", err)
+	}
+}
+
+func TestBlockMessage(t *testing.T) {
+	if got := NewChecker(config.Grounding{}, nil).BlockMessage(); got != defaultBlockMessage {
+		t.Errorf("default block message %q", got)
+	}
+	if got := NewChecker(config.Grounding{BlockMessage: "Нет"}, nil).BlockMessage(); got != "Нет" {
+		t.Errorf("configured block message %q", got)
+	}
+}
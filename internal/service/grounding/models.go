@@ -0,0 +1,31 @@
+package grounding
+
+const (
+	ActionFlag  = "flag"
+	ActionBlock = "block"
+)
+
+// Report is the outcome of checking an answer against its sources.
+type Report struct {
+	// Score is the share of answer sentences supported by the sources.
+	Score       float64 `json:"score"`
+	Passed      bool    `json:"passed"`
+	Blocked     bool    `json:"blocked,omitempty"`
+	Unsupported []Span  `json:"unsupported,omitempty"`
+}
+
+// Span is a sentence of the answer. Start and End are character offsets.
+type Span struct {
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+type verdicts struct {
+	Verdicts []verdict `json:"verdicts"`
+}
+
+type verdict struct {
+	Sentence  int  `json:"sentence"`
+	Supported bool `json:"supported"`
+}
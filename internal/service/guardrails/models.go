@@ -0,0 +1,63 @@
+package guardrails
+
+import (
+	"ai-service/internal/util/config"
+	"fmt"
+)
+
+const (
+	ActionBlock      = "block"
+	ActionNeutralise = "neutralise"
+
+	ScopeUser     = "user"
+	ScopeDocument = "document"
+	ScopeBoth     = "both"
+)
+
+// BlockedError is returned when a user message is rejected.
+type BlockedError struct {
+	Rule string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("message rejected by content policy (%s)", e.Rule)
+}
+
+// defaultRules catch the common instruction-override phrasings in English
+// and Russian, chat role markers and requests for the system prompt.
+var defaultRules = []config.GuardrailRule{
+	{
+		Name:    "ignore_instructions",
+		Pattern: `(?i)\b(ignore|disregard|forget|override)\s+(all\s+|any\s+)?(the\s+|your\s+)?(previous|prior|above|earlier|system)\s+(instructions|prompts?|rules|messages)`,
+		Action:  ActionBlock,
+		Scope:   ScopeBoth,
+	},
+	{
+		Name:    "ignore_instructions_ru",
+		Pattern: `(?i)(игнорируй|забудь|не\s+обращай\s+внимания\s+на|отмени)\s+(все\s+)?(предыдущие|прошлые|вышеуказанные|системные|прежние)\s+(инструкции|указания|правила|сообщения)`,
+		Action:  ActionBlock,
+		Scope:   ScopeBoth,
+	},
+	{
+		Name:    "reveal_prompt",
+		Pattern: `(?i)(reveal|print|show|repeat|покажи|выведи|повтори)\s+(me\s+)?(your\s+|the\s+|свой\s+|свои\s+|твой\s+)?(system\s+prompt|initial\s+instructions|системный\s+промпт|системные\s+инструкции)`,
+		Action:  ActionBlock,
+		Scope:   ScopeUser,
+	},
+	{
+		Name:    "role_markers",
+		Pattern: `(?im)(<\|?/?(im_start|im_end|system|assistant)\|?>|^\s*(system|assistant|###\s*instruction)\s*:)`,
+		Action:  ActionNeutralise,
+		Scope:   ScopeBoth,
+	},
+	{
+		Name:    "role_override",
+		Pattern: `(?i)(\byou\s+are\s+now\b|\bfrom\s+now\s+on\s+you\b|теперь\s+ты\s|с\s+этого\s+момента\s+ты\s)`,
+		Action:  ActionNeutralise,
+		Scope:   ScopeDocument,
+	},
+}
+
+type classification struct {
+	Flagged []int `json:"flagged"`
+}
@@ -0,0 +1,75 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	ScopeChat   = "chat"
+	ScopeUpload = "upload"
+)
+
+var (
+	// ErrConflict is returned when a request ID is reused with a different payload.
+	ErrConflict = errors.New("request id was already used with a different payload")
+	// ErrInProgress is returned when the original request is still being processed.
+	ErrInProgress = errors.New("request with this id is still being processed")
+)
+
+// Record is the stored outcome of a request made under a client-supplied ID.
+// StatusCode is zero while the original request is still in flight.
+type Record struct {
+	RequestID   string          `db:"request_id"`
+	UserID      string          `db:"user_id"`
+	Scope       string          `db:"scope"`
+	PayloadHash string          `db:"payload_hash"`
+	StatusCode  int             `db:"status_code"`
+	Response    json.RawMessage `db:"response"`
+	ExpiresAt   time.Time       `db:"expires_at"`
+}
+
+func (r *Record) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// Replay decides what a request that reuses the ID of existing gets: the
+// stored outcome of existing, ErrConflict when the payloads differ or
+// ErrInProgress while the original request is still processed.
+func (r *Record) Replay(existing *Record) (*Record, error) {
+	if existing.PayloadHash != r.PayloadHash {
+		return nil, ErrConflict
+	}
+	if !existing.Completed() {
+		return nil, ErrInProgress
+	}
+	return existing, nil
+}
+
+// NewRecord builds the reservation for a request made under requestID.
+func NewRecord(scope, userID, requestID string, payload any) (*Record, error) {
+	hash, err := Hash(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Record{
+		RequestID:   requestID,
+		UserID:      userID,
+		Scope:       scope,
+		PayloadHash: hash,
+	}, nil
+}
+
+// Hash fingerprints a request payload so retries can be told apart from
+// conflicting reuse of the same request ID.
+func Hash(payload any) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
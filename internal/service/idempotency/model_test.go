@@ -0,0 +1,60 @@
+package idempotency
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type payload struct {
+	RqUID    string   `json:"rquid"`
+	Messages []string `json:"messages"`
+}
+
+func TestReplay(t *testing.T) {
+	first, err := NewRecord(ScopeChat, "user", "req-1", payload{RqUID: "req-1", Messages: []string{"Привет"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	same, _ := NewRecord(ScopeChat, "user", "req-1", payload{RqUID: "req-1", Messages: []string{"Привет"}})
+	other, _ := NewRecord(ScopeChat, "user", "req-1", payload{RqUID: "req-1", Messages: []string{"Пока"}})
+
+	if _, err := same.Replay(first); !errors.Is(err, ErrInProgress) {
+		t.Errorf("retry while in flight: %v, want ErrInProgress", err)
+	}
+
+	first.StatusCode, first.Response = 200, json.RawMessage(`{"message_id":"m-1"}`)
+	got, err := same.Replay(first)
+	if err != nil {
+		t.Fatalf("retry after completion: %v", err)
+	}
+	if got.StatusCode != 200 || string(got.Response) != `{"message_id":"m-1"}` {
+		t.Errorf("replayed %d %s, want the stored response", got.StatusCode, got.Response)
+	}
+
+	if _, err := other.Replay(first); !errors.Is(err, ErrConflict) {
+		t.Errorf("different payload: %v, want ErrConflict", err)
+	}
+	first.StatusCode = 0
+	if _, err := other.Replay(first); !errors.Is(err, ErrConflict) {
+		t.Errorf("different payload in flight: %v, want ErrConflict", err)
+	}
+}
+
+func TestHash(t *testing.T) {
+	a, err := Hash(map[string]any{"a": 1, "b": []string{"x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := Hash(map[string]any{"b": []string{"x"}, "a": 1})
+	c, _ := Hash(map[string]any{"a": 2, "b": []string{"x"}})
+	if a != b {
+		t.Error("equal payloads hash differently")
+	}
+	if a == c {
+		t.Error("different payloads hash alike")
+	}
+	if _, err := Hash(func() {}); err == nil {
+		t.Error("a payload that cannot be encoded was hashed")
+	}
+}
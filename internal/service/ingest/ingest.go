@@ -0,0 +1,245 @@
+package ingest
+
+import (
+	"ai-service/internal/repository"
+	"ai-service/internal/repository/postgres"
+	"ai-service/internal/service/document"
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/service/redaction"
+	"ai-service/internal/util/config"
+	"ai-service/internal/util/doc"
+	"context"
+	stdErrors "errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Progress, in percent, at the start of each stage. Embedding advances
+// from embedStart to storeStart batch by batch.
+const (
+	extractStart = 5
+	embedStart   = 30
+	storeStart   = 90
+)
+
+// Ingester works through the ingestion queue: it extracts the text of a
+// queued document, embeds the chunks and stores them in the vector
+// database, recording the status of the document as it goes.
+type Ingester struct {
+	config     *config.Config
+	repository *repository.Repository
+	llm        ollama.LLMService
+	documents  *postgres.DocumentRepository
+	jobs       *postgres.JobRepository
+	redactor   *redaction.Redactor
+	ocr        doc.OCR
+
+	wake chan struct{}
+}
+
+func NewIngester(cfg *config.Config, repo *repository.Repository, llm ollama.LLMService, documents *postgres.DocumentRepository, jobs *postgres.JobRepository, redactor *redaction.Redactor, ocr doc.OCR) *Ingester {
+	return &Ingester{
+		config:     cfg,
+		repository: repo,
+		llm:        llm,
+		documents:  documents,
+		jobs:       jobs,
+		redactor:   redactor,
+		ocr:        ocr,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// Wake tells an idle worker that a job was queued, so it does not wait
+// for the next poll.
+func (i *Ingester) Wake() {
+	select {
+	case i.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run starts the workers and returns when ctx is done and they stopped.
+// Jobs interrupted by the shutdown go back to the queue.
+func (i *Ingester) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for n := 0; n < i.config.Ingestion.Workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			i.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (i *Ingester) work(ctx context.Context) {
+	ticker := time.NewTicker(i.config.Ingestion.PollInterval)
+	defer ticker.Stop()
+	for {
+		job, err := i.jobs.Claim(ctx, i.config.Ingestion.Lease)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("ingest: %v", err)
+		}
+		if job != nil {
+			i.process(ctx, job)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-i.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// process runs one job. Documents that cannot be read fail at once; other
+// errors are retried with a growing delay until the attempts run out.
+func (i *Ingester) process(ctx context.Context, job *document.Job) {
+	// Chunks are embedded redacted; placeholders are stable per org, so
+	// they match the ones in redacted questions.
+	ctx = i.redactor.Begin(ctx, job.UserID)
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	go i.hold(jobCtx, job, cancel)
+	err := i.ingest(jobCtx, job)
+	lost := stdErrors.Is(err, postgres.ErrLeaseLost) || stdErrors.Is(context.Cause(jobCtx), postgres.ErrLeaseLost)
+	cancel(nil)
+	// Bookkeeping goes on during a shutdown so the job is not left leased.
+	bg := context.WithoutCancel(ctx)
+	switch {
+	case lost:
+		// Another worker has the job now and does the bookkeeping, unless
+		// the job is gone with its document.
+		log.Printf("ingest: document %s, attempt %d: %v", job.DocumentID, job.Attempts, postgres.ErrLeaseLost)
+		if _, err := i.documents.GetByID(bg, job.DocumentID); stdErrors.Is(err, postgres.ErrDocumentNotFound) {
+			if err := i.repository.Vector.DeleteDoc(bg, job.UserID, job.DocumentID); err != nil {
+				log.Printf("ingest: delete chunks of %s: %v", job.DocumentID, err)
+			}
+		}
+	case err == nil:
+		if err := i.jobs.Delete(bg, job.DocumentID); err != nil {
+			log.Printf("ingest: delete job %s: %v", job.DocumentID, err)
+		}
+	case ctx.Err() != nil:
+		if err := i.jobs.Release(bg, job.DocumentID); err != nil {
+			log.Printf("ingest: release job %s: %v", job.DocumentID, err)
+		}
+		if err := i.documents.SetProgress(bg, job.DocumentID, document.StatusQueued, 0); err != nil {
+			log.Printf("ingest: document %s: %v", job.DocumentID, err)
+		}
+	case stdErrors.Is(err, postgres.ErrDocumentNotFound):
+		// Deleted while being ingested; drop what was stored meanwhile.
+		if err := i.repository.Vector.DeleteDoc(bg, job.UserID, job.DocumentID); err != nil {
+			log.Printf("ingest: delete chunks of %s: %v", job.DocumentID, err)
+		}
+		if err := i.jobs.Delete(bg, job.DocumentID); err != nil {
+			log.Printf("ingest: delete job %s: %v", job.DocumentID, err)
+		}
+	default:
+		retry := !permanent(err) && job.Attempts < i.config.Ingestion.MaxAttempts
+		log.Printf("ingest: document %s, attempt %d: %v", job.DocumentID, job.Attempts, err)
+		if err := i.documents.Fail(bg, job.DocumentID, err.Error(), retry); err != nil {
+			log.Printf("ingest: document %s: %v", job.DocumentID, err)
+		}
+		if retry {
+			err = i.jobs.Lease(bg, job.DocumentID, time.Duration(job.Attempts)*time.Minute)
+		} else {
+			err = i.jobs.Delete(bg, job.DocumentID)
+		}
+		if err != nil {
+			log.Printf("ingest: job %s: %v", job.DocumentID, err)
+		}
+	}
+}
+
+func (i *Ingester) ingest(ctx context.Context, job *document.Job) error {
+	if job.Attempts > 1 {
+		// An earlier attempt may have stored part of the chunks.
+		if err := i.repository.Vector.DeleteDoc(ctx, job.UserID, job.DocumentID); err != nil {
+			log.Printf("ingest: delete chunks of %s: %v", job.DocumentID, err)
+		}
+	}
+
+	if err := i.progress(ctx, job, document.StatusExtracting, extractStart); err != nil {
+		return err
+	}
+	result, err := doc.Read(ctx, job.Data, job.Filename, doc.Options{
+		Chunking:   i.config.Chunking,
+		Extraction: i.config.Extraction,
+		OCR:        i.ocr,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := i.progress(ctx, job, document.StatusEmbedding, embedStart); err != nil {
+		return err
+	}
+	chunks := result.Chunks
+	embeddings := make([][][]float32, 0, len(chunks))
+	for start := 0; start < len(chunks); start += i.config.Ingestion.EmbedBatch {
+		end := min(start+i.config.Ingestion.EmbedBatch, len(chunks))
+		texts := make([]string, 0, end-start)
+		for _, chunk := range chunks[start:end] {
+			texts = append(texts, chunk.Text)
+		}
+		batch, err := i.llm.Embed(ctx, texts)
+		if err != nil {
+			return err
+		}
+		embeddings = append(embeddings, batch...)
+		progress := embedStart + (storeStart-embedStart)*end/len(chunks)
+		if err := i.progress(ctx, job, document.StatusEmbedding, progress); err != nil {
+			return err
+		}
+	}
+
+	// The stores are not undone, so make sure the job is still ours.
+	if err := i.jobs.Renew(ctx, job, i.config.Ingestion.Lease); err != nil {
+		return err
+	}
+	if err := i.repository.Vector.SaveDoc(ctx, job.UserID, job.DocumentID, chunks, embeddings); err != nil {
+		return err
+	}
+	if err := i.jobs.Renew(ctx, job, i.config.Ingestion.Lease); err != nil {
+		return err
+	}
+	return i.documents.Indexed(ctx, job.DocumentID, result.Report.Title, &result.Report)
+}
+
+// hold renews the lease of job until ctx is done, so that no step of the
+// job may outlast it. When another worker took the job over, hold cancels
+// ctx with ErrLeaseLost.
+func (i *Ingester) hold(ctx context.Context, job *document.Job, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(i.config.Ingestion.Lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := i.jobs.Renew(ctx, job, i.config.Ingestion.Lease)
+		if stdErrors.Is(err, postgres.ErrLeaseLost) {
+			cancel(err)
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("ingest: job %s: %v", job.DocumentID, err)
+		}
+	}
+}
+
+// progress records the stage of a job.
+func (i *Ingester) progress(ctx context.Context, job *document.Job, status string, percent int) error {
+	return i.documents.SetProgress(ctx, job.DocumentID, status, percent)
+}
+
+// permanent tells errors that a retry cannot fix: the document itself
+// cannot be read.
+func permanent(err error) bool {
+	var unsupported *doc.UnsupportedFormatError
+	return stdErrors.As(err, &unsupported) || stdErrors.Is(err, doc.ErrNoText)
+}
@@ -0,0 +1,50 @@
+package ingest
+
+import (
+	"ai-service/internal/repository/postgres"
+	"ai-service/internal/util/config"
+	"ai-service/internal/util/doc"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPermanent(t *testing.T) {
+	_, unsupported := doc.Read(context.Background(), []byte("\x00\x01\x02garbage"), "data.bin", doc.Options{})
+	_, noText := doc.Read(context.Background(), []byte(" \n\t"), "empty.txt", doc.Options{Chunking: config.Chunking{Size: 100, Unit: "char"}})
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"unsupported format", unsupported, true},
+		{"no text", noText, true},
+		{"wrapped no text", fmt.Errorf("%w: страницы 1, 2 содержат только изображения", doc.ErrNoText), true},
+		{"embedding failure", errors.New("connection refused"), false},
+		{"lost lease", postgres.ErrLeaseLost, false},
+		{"cancelled", context.Canceled, false},
+	}
+	for _, tt := range tests {
+		if got := permanent(tt.err); got != tt.want {
+			t.Errorf("%s: permanent(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWake(t *testing.T) {
+	i := NewIngester(&config.Config{}, nil, nil, nil, nil, nil, nil)
+	// Wakes coalesce while no worker is waiting, and never block.
+	i.Wake()
+	i.Wake()
+	select {
+	case <-i.wake:
+	default:
+		t.Fatal("no wake-up was delivered")
+	}
+	select {
+	case <-i.wake:
+		t.Fatal("a second wake-up was queued")
+	default:
+	}
+}
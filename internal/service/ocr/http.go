@@ -0,0 +1,51 @@
+package ocr
+
+import (
+	"ai-service/internal/util/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// httpOCR posts the image as the request body to an OCR service, which
+// answers with {"text": "..."}.
+type httpOCR struct {
+	config config.OCR
+	client *http.Client
+}
+
+func (o *httpOCR) Recognize(ctx context.Context, image []byte, mediaType string) (string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, o.config.URL, bytes.NewReader(image))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", mediaType)
+	request.Header.Set("Accept", "application/json")
+	if o.config.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+o.config.Token)
+	}
+
+	response, err := o.client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, 10<<20))
+	if err != nil {
+		return "", err
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ocr service returned %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("ocr service returned invalid json: %w", err)
+	}
+	return result.Text, nil
+}
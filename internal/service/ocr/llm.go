@@ -0,0 +1,65 @@
+package ocr
+
+import (
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/config"
+	"context"
+	"encoding/base64"
+	"fmt"
+)
+
+const ocrPrompt = "Распознай весь текст на изображении и перепиши его дословно в порядке чтения. " +
+	"Заголовки начинай с #, таблицы оформи в Markdown. " +
+	"Ничего не добавляй от себя и не описывай изображение. Если текста нет, ответь пустой строкой."
+
+// visionOCR has a vision model transcribe the image.
+type visionOCR struct {
+	model string
+	llm   ollama.LLMService
+}
+
+func (o *visionOCR) Recognize(ctx context.Context, image []byte, mediaType string) (string, error) {
+	ctx = ollama.WithPriority(ctx, ollama.PriorityBackground)
+	if err := o.checkVision(ctx); err != nil {
+		return "", err
+	}
+
+	temperature := 0.0
+	opts := []ollama.RequestOption{
+		ollama.WithOptions(&config.GenerationOptions{Temperature: &temperature}),
+	}
+	if o.model != "" {
+		opts = append(opts, ollama.WithModel(o.model))
+	}
+	response, err := o.llm.Chat(ctx, []ollama.Message{{
+		Role:    "user",
+		Content: ocrPrompt,
+		Images:  []string{base64.StdEncoding.EncodeToString(image)},
+	}}, opts...)
+	if err != nil {
+		return "", err
+	}
+	return response.Message.Content, nil
+}
+
+func (o *visionOCR) checkVision(ctx context.Context) error {
+	model, vision := o.model, false
+	if model == "" {
+		model = o.llm.Models().Chat
+		ok, err := o.llm.SupportsVision(ctx)
+		if err != nil {
+			return err
+		}
+		vision = ok
+	} else {
+		info, err := o.llm.ShowModel(ctx, model)
+		if err != nil {
+			return err
+		}
+		vision = info.HasVision()
+	}
+	if !vision {
+		return fmt.Errorf("model %s does not support images", model)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package ocr
+
+import (
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/config"
+	"ai-service/internal/util/doc"
+	"fmt"
+	"net/http"
+)
+
+const (
+	BackendLLM  = "llm"
+	BackendHTTP = "http"
+)
+
+// New returns the OCR backend chosen in cfg, or nil when OCR is off.
+func New(cfg config.OCR, llm ollama.LLMService) (doc.OCR, error) {
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case BackendLLM:
+		return &visionOCR{model: cfg.Model, llm: llm}, nil
+	case BackendHTTP:
+		return &httpOCR{config: cfg, client: &http.Client{Timeout: cfg.Timeout}}, nil
+	}
+	return nil, fmt.Errorf("unknown ocr backend %q", cfg.Backend)
+}
@@ -0,0 +1,141 @@
+package ocr
+
+import (
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/config"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// visionLLM serves a chat model that sees images when vision is set and
+// a separate model, "ocr-model", described by info.
+type visionLLM struct {
+	ollama.LLMService
+	vision bool
+	info   ollama.ModelInfo
+	calls  []ollama.ChatRequest
+}
+
+func (v *visionLLM) Models() ollama.ActiveModels {
+	return ollama.ActiveModels{Chat: "llama3"}
+}
+
+func (v *visionLLM) SupportsVision(context.Context) (bool, error) {
+	return v.vision, nil
+}
+
+func (v *visionLLM) ShowModel(_ context.Context, name string) (*ollama.ModelInfo, error) {
+	if name != "ocr-model" {
+		return nil, fmt.Errorf("model %s not found", name)
+	}
+	return &v.info, nil
+}
+
+func (v *visionLLM) Chat(_ context.Context, messages []ollama.Message, opts ...ollama.RequestOption) (*ollama.ChatResponse, error) {
+	req := ollama.ChatRequest{Messages: messages}
+	for _, opt := range opts {
+		opt(&req)
+	}
+	v.calls = append(v.calls, req)
+	return &ollama.ChatResponse{Message: ollama.Message{Role: "assistant", Content: "Распознанный текст"}}, nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		backend string
+		want    string
+		err     bool
+	}{
+		{"", "<nil>", false},
+		{BackendLLM, "*ocr.visionOCR", false},
+		{BackendHTTP, "*ocr.httpOCR", false},
+		{"tesseract", "<nil>", true},
+	}
+	for _, tt := range tests {
+		got, err := New(config.OCR{Backend: tt.backend, Timeout: time.Second}, &visionLLM{})
+		if (err != nil) != tt.err || fmt.Sprintf("%T", got) != tt.want {
+			t.Errorf("New(%q) = %T, %v", tt.backend, got, err)
+		}
+	}
+}
+
+func TestVisionOCR(t *testing.T) {
+	image := []byte("\x89PNG\r\n\x1a\n")
+	tests := []struct {
+		name  string
+		model string
+		llm   *visionLLM
+		err   string
+	}{
+		{"chat model", "", &visionLLM{vision: true}, ""},
+		{"chat model without vision", "", &visionLLM{}, "model llama3 does not support images"},
+		{"own model", "ocr-model", &visionLLM{info: ollama.ModelInfo{Capabilities: []string{"vision"}}}, ""},
+		{"own model without vision", "ocr-model", &visionLLM{vision: true}, "model ocr-model does not support images"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := (&visionOCR{model: tt.model, llm: tt.llm}).Recognize(context.Background(), image, "image/png")
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("got %v, want %q", err, tt.err)
+				}
+				if len(tt.llm.calls) != 0 {
+					t.Error("a model without vision was sent the image")
+				}
+				return
+			}
+			if err != nil || text != "Распознанный текст" {
+				t.Fatalf("got %q, %v", text, err)
+			}
+			req := tt.llm.calls[0]
+			if req.Model != tt.model || *req.Options.Temperature != 0 {
+				t.Errorf("request model %q, options %+v", req.Model, req.Options)
+			}
+			if images := req.Messages[0].Images; len(images) != 1 || images[0] != base64.StdEncoding.EncodeToString(image) {
+				t.Errorf("request images %q", images)
+			}
+		})
+	}
+}
+
+func TestHTTPOCR(t *testing.T) {
+	var contentType, auth, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		contentType, auth, body = r.Header.Get("Content-Type"), r.Header.Get("Authorization"), string(raw)
+		switch string(raw) {
+		case "broken":
+			http.Error(w, "cannot read image", http.StatusUnprocessableEntity)
+		case "garbled":
+			fmt.Fprint(w, "text")
+		default:
+			fmt.Fprint(w, `{"text": "Договор № 7"}`)
+		}
+	}))
+	defer srv.Close()
+
+	o, err := New(config.OCR{Backend: BackendHTTP, URL: srv.URL, Token: "secret", Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := o.Recognize(context.Background(), []byte("jpeg"), "image/jpeg")
+	if err != nil || text != "Договор № 7" {
+		t.Fatalf("got %q, %v", text, err)
+	}
+	if contentType != "image/jpeg" || auth != "Bearer secret" || body != "jpeg" {
+		t.Errorf("request content type %q, authorization %q, body %q", contentType, auth, body)
+	}
+
+	for image, want := range map[string]string{"broken": "422 Unprocessable Entity: cannot read image", "garbled": "invalid json"} {
+		if _, err := o.Recognize(context.Background(), []byte(image), "image/png"); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got %v, want %q", image, err, want)
+		}
+	}
+}
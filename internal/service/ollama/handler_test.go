@@ -0,0 +1,99 @@
+package ollama
+
+import (
+	"ai-service/internal/util/config"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testService(urls ...string) *llmService {
+	return &llmService{
+		config:       &config.Config{},
+		client:       http.DefaultClient,
+		streamClient: http.DefaultClient,
+		pool:         newPool(urls),
+		scheduler:    newScheduler(1, 10),
+	}
+}
+
+// downURL is the address of a server that no longer listens.
+func downURL() string {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	return srv.URL
+}
+
+func TestHandlerWithoutNodes(t *testing.T) {
+	_, _, err := testService().handler(context.Background(), http.MethodGet, tags, "", nil)
+	if !errors.Is(err, ErrNoNode) {
+		t.Fatalf("got %v, want ErrNoNode", err)
+	}
+	if got := HTTPStatus(err); got != http.StatusServiceUnavailable {
+		t.Errorf("HTTPStatus(ErrNoNode) = %d, want 503", got)
+	}
+}
+
+func TestHandlerFailsOver(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"models": []}`)
+	}))
+	defer up.Close()
+
+	l := testService(downURL(), up.URL)
+	l.pool.nodes[1].outstanding.Store(1) // try the node down first
+	body, status, err := l.handler(context.Background(), http.MethodGet, tags, "", nil)
+	if err != nil || status != http.StatusOK || !strings.Contains(string(body), "models") {
+		t.Fatalf("got %q, %d, %v, want the answer of the node up", body, status, err)
+	}
+	if l.pool.nodes[0].status().Healthy {
+		t.Error("the node down is still healthy")
+	}
+
+	l = testService(downURL())
+	if _, _, err := l.handler(context.Background(), http.MethodGet, tags, "", nil); !errors.Is(err, ErrNoNode) {
+		t.Errorf("every node down: %v, want ErrNoNode", err)
+	}
+}
+
+func TestPullModelReportsEveryNode(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/pull" {
+			fmt.Fprintln(w, `{"status": "pulling"}`)
+			fmt.Fprintln(w, `{"status": "success"}`)
+			return
+		}
+		fmt.Fprint(w, `{"models": []}`)
+	}))
+	defer up.Close()
+	down := downURL()
+
+	l := testService(down, up.URL)
+	var lines []PullProgress
+	err := l.PullModel(context.Background(), "llama3", func(p *PullProgress) error {
+		lines = append(lines, *p)
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 nodes: "+down) {
+		t.Fatalf("got %v, want the node down reported", err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("got %d progress lines, want 3: %+v", len(lines), lines)
+	}
+	if lines[0].Node != down || lines[0].Status != "error" || lines[0].Error == "" {
+		t.Errorf("first line %+v, want the error of the node down", lines[0])
+	}
+	if lines[2].Node != up.URL || lines[2].Status != "success" {
+		t.Errorf("last line %+v, want the pull on the node up to finish", lines[2])
+	}
+
+	stop := errors.New("client left")
+	err = l.PullModel(context.Background(), "llama3", func(p *PullProgress) error { return stop })
+	if !errors.Is(err, stop) {
+		t.Errorf("got %v, want the error of fn", err)
+	}
+}
@@ -0,0 +1,296 @@
+package ollama
+
+import (
+	"ai-service/internal/repository/vector"
+	"ai-service/internal/util/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const healthPrompt = "ping"
+
+func (l *llmService) Models() ActiveModels {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.active
+}
+
+// SwitchModels checks that the new models are installed and answer before
+// making them active. An empty field keeps the current model. The
+// embedding model only changes while indexed reports no documents, since
+// old chunks could not be searched with the new model.
+func (l *llmService) SwitchModels(ctx context.Context, models ActiveModels, indexed func(context.Context) (int, error)) error {
+	l.switchMu.Lock()
+	defer l.switchMu.Unlock()
+
+	current := l.Models()
+	if models.Chat == "" {
+		models.Chat = current.Chat
+	}
+	if models.Embedding == "" {
+		models.Embedding = current.Embedding
+	}
+
+	if models.Chat != current.Chat {
+		if err := l.checkChatModel(ctx, models.Chat); err != nil {
+			return fmt.Errorf("chat model %s failed health check: %w", models.Chat, err)
+		}
+	}
+	if models.Embedding != current.Embedding {
+		if err := l.checkEmbeddingModel(ctx, models.Embedding); err != nil {
+			return fmt.Errorf("embedding model %s failed health check: %w", models.Embedding, err)
+		}
+		// Counted last, right before the switch, to leave uploads the
+		// shortest window.
+		n, err := indexed(ctx)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return fmt.Errorf("%w: %d documents", ErrEmbeddingInUse, n)
+		}
+	}
+
+	l.mu.Lock()
+	l.active = models
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *llmService) checkChatModel(ctx context.Context, model string) error {
+	predict := 1
+	_, err := l.Chat(ctx, []Message{{Role: "user", Content: healthPrompt}},
+		WithModel(model), WithOptions(&config.GenerationOptions{NumPredict: &predict}))
+	return err
+}
+
+// checkEmbeddingModel also makes sure the embeddings fit the existing
+// collections, since mixing dimensions would break every search.
+func (l *llmService) checkEmbeddingModel(ctx context.Context, model string) error {
+	embeddings, err := l.embed(ctx, model, []string{healthPrompt})
+	if err != nil {
+		return err
+	}
+	if len(embeddings) == 0 || len(embeddings[0]) == 0 {
+		return errors.New("model returned no embedding")
+	}
+	if dim := len(embeddings[0][0]); dim != vector.Dim {
+		return fmt.Errorf("embedding dimension %d does not match the vector store dimension %d", dim, vector.Dim)
+	}
+	return nil
+}
+
+func (l *llmService) ListModels(ctx context.Context) (*ModelList, error) {
+	return l.modelList(ctx, tags)
+}
+
+func (l *llmService) RunningModels(ctx context.Context) (*ModelList, error) {
+	return l.modelList(ctx, running)
+}
+
+// modelList merges the lists of every reachable node, recording which
+// nodes each model is on.
+func (l *llmService) modelList(ctx context.Context, endpoint string) (*ModelList, error) {
+	merged := make(map[string]*ModelSummary)
+	var names []string
+	var lastErr error
+	for _, n := range l.pool.nodes {
+		respBody, status, err := l.handlerOn(ctx, n, http.MethodGet, endpoint, nil)
+		if err == nil && status != http.StatusOK {
+			err = errors.New(string(respBody))
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var list ModelList
+		if err := json.Unmarshal(respBody, &list); err != nil {
+			return nil, err
+		}
+		for _, m := range list.Models {
+			if existing, ok := merged[m.Name]; ok {
+				existing.Nodes = append(existing.Nodes, n.url)
+				continue
+			}
+			m.Nodes = []string{n.url}
+			merged[m.Name] = &m
+			names = append(names, m.Name)
+		}
+	}
+	if len(names) == 0 && lastErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoNode, lastErr)
+	}
+	sort.Strings(names)
+	list := &ModelList{Models: make([]ModelSummary, 0, len(names))}
+	for _, name := range names {
+		list.Models = append(list.Models, *merged[name])
+	}
+	return list, nil
+}
+
+func (l *llmService) ShowModel(ctx context.Context, name string) (*ModelInfo, error) {
+	respBody, status, err := l.handler(ctx, http.MethodPost, show, name, modelRequest{Model: name})
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+		var info ModelInfo
+		if err := json.Unmarshal(respBody, &info); err != nil {
+			return nil, err
+		}
+		return &info, nil
+	case http.StatusNotFound:
+		return nil, ErrModelNotFound
+	default:
+		return nil, errors.New(string(respBody))
+	}
+}
+
+// PullModel pulls the model on every node in turn so that any of them can
+// serve it afterwards. Progress lines carry the node they came from. A node
+// that fails is reported with an error line and the pull goes on with the
+// next one; the returned error lists the nodes that failed. An error from
+// fn stops the pull.
+func (l *llmService) PullModel(ctx context.Context, name string, fn func(*PullProgress) error) error {
+	if len(l.pool.nodes) == 0 {
+		return ErrNoNode
+	}
+	stream := true
+	var failed []string
+	for _, n := range l.pool.nodes {
+		var fnErr error
+		err := l.streamOn(ctx, n, pull, modelRequest{Model: name, Stream: &stream}, func(line []byte) error {
+			var progress PullProgress
+			if err := json.Unmarshal(line, &progress); err != nil {
+				return err
+			}
+			if progress.Error != "" {
+				return errors.New(progress.Error)
+			}
+			progress.Node = n.url
+			fnErr = fn(&progress)
+			return fnErr
+		})
+		if fnErr != nil {
+			return fnErr
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			failed = append(failed, n.url)
+			if err := fn(&PullProgress{Status: "error", Error: err.Error(), Node: n.url}); err != nil {
+				return err
+			}
+			continue
+		}
+		l.probe(ctx, n)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("pull failed on %d of %d nodes: %s", len(failed), len(l.pool.nodes), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// KeepAlive loads the model and keeps it in memory for keepAlive ("-1"
+// forever), or unloads it right away when keepAlive is "0". Loading goes to
+// one node picked like any other request; unloading goes to every node.
+func (l *llmService) KeepAlive(ctx context.Context, name string, keepAlive string) error {
+	stream := false
+	req := modelRequest{
+		Model:     name,
+		KeepAlive: keepAlive,
+		Stream:    &stream,
+	}
+	if keepAlive != "0" {
+		respBody, status, err := l.handler(ctx, http.MethodPost, generate, name, req)
+		return keepAliveResult(respBody, status, err)
+	}
+
+	found := false
+	for _, n := range l.pool.nodes {
+		respBody, status, err := l.handlerOn(ctx, n, http.MethodPost, generate, req)
+		err = keepAliveResult(respBody, status, err)
+		if errors.Is(err, ErrModelNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("unload on %s: %w", n.url, err)
+		}
+		found = true
+		n.mu.Lock()
+		delete(n.loaded, normalizeModel(name))
+		n.mu.Unlock()
+	}
+	if !found {
+		return ErrModelNotFound
+	}
+	return nil
+}
+
+func keepAliveResult(respBody []byte, status int, err error) error {
+	if err != nil {
+		return err
+	}
+	switch status {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return ErrModelNotFound
+	default:
+		return errors.New(strings.TrimSpace(string(respBody)))
+	}
+}
+
+// SupportsVision reports whether the active chat model accepts images. The
+// answer is cached per model since it only changes when a model is replaced.
+func (l *llmService) SupportsVision(ctx context.Context) (bool, error) {
+	model := l.Models().Chat
+	l.mu.RLock()
+	ok, cached := l.vision[model]
+	l.mu.RUnlock()
+	if cached {
+		return ok, nil
+	}
+
+	info, err := l.ShowModel(ctx, model)
+	if err != nil {
+		return false, err
+	}
+	ok = info.HasVision()
+	l.mu.Lock()
+	l.vision[model] = ok
+	l.mu.Unlock()
+	return ok, nil
+}
+
+// HasVision reports whether the model accepts images. It uses the
+// capabilities list when Ollama reports it and falls back to the model
+// families and metadata on older versions.
+func (m *ModelInfo) HasVision() bool {
+	if len(m.Capabilities) > 0 {
+		for _, c := range m.Capabilities {
+			if c == "vision" {
+				return true
+			}
+		}
+		return false
+	}
+	for _, family := range m.Details.Families {
+		if family == "clip" || family == "mllama" {
+			return true
+		}
+	}
+	for key := range m.ModelInfo {
+		if strings.Contains(key, ".vision.") {
+			return true
+		}
+	}
+	return false
+}
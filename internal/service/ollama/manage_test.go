@@ -0,0 +1,50 @@
+package ollama
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHasVision(t *testing.T) {
+	tests := []struct {
+		name string
+		info ModelInfo
+		want bool
+	}{
+		{"capability", ModelInfo{Capabilities: []string{"completion", "vision"}}, true},
+		{"capabilities without vision", ModelInfo{Capabilities: []string{"completion"}, Details: ModelDetails{Families: []string{"clip"}}}, false},
+		{"clip family", ModelInfo{Details: ModelDetails{Families: []string{"llama", "clip"}}}, true},
+		{"vision metadata", ModelInfo{ModelInfo: map[string]any{"gemma3.vision.block_count": 27}}, true},
+		{"text model", ModelInfo{Details: ModelDetails{Families: []string{"llama"}}}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.info.HasVision(); got != tt.want {
+			t.Errorf("%s: HasVision() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSupportsVisionIsCached(t *testing.T) {
+	shows := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		shows++
+		fmt.Fprint(w, `{"capabilities": ["completion", "vision"]}`)
+	}))
+	defer srv.Close()
+
+	l := testService(srv.URL)
+	l.active = ActiveModels{Chat: "llava"}
+	l.vision = make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		ok, err := l.SupportsVision(context.Background())
+		if err != nil || !ok {
+			t.Fatalf("SupportsVision = %v, %v", ok, err)
+		}
+	}
+	if shows != 1 {
+		t.Errorf("model shown %d times, want once", shows)
+	}
+}
@@ -1,11 +1,66 @@
 package ollama
 
-import "time"
+import (
+	"ai-service/internal/util/config"
+	"encoding/json"
+	"time"
+)
 
 type ChatRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
-	Stream   bool      `json:"stream"`
+	Model     string                    `json:"model"`
+	Messages  []Message                 `json:"messages"`
+	Stream    bool                      `json:"stream"`
+	Tools     []Tool                    `json:"tools,omitempty"`
+	Format    json.RawMessage           `json:"format,omitempty"`
+	Options   *config.GenerationOptions `json:"options,omitempty"`
+	KeepAlive string                    `json:"keep_alive,omitempty"`
+}
+
+func (r ChatRequest) effectiveOptions() *config.GenerationOptions {
+	var options config.GenerationOptions
+	if r.Options != nil {
+		options = *r.Options
+	}
+	options.KeepAlive = r.KeepAlive
+	return &options
+}
+
+// RequestOption adjusts a chat request before it is sent to Ollama.
+type RequestOption func(*ChatRequest)
+
+// WithFormat constrains the reply to the given JSON schema.
+func WithFormat(schema json.RawMessage) RequestOption {
+	return func(r *ChatRequest) {
+		r.Format = schema
+	}
+}
+
+// WithOptions layers opts over the options already on the request.
+func WithOptions(opts *config.GenerationOptions) RequestOption {
+	return func(r *ChatRequest) {
+		if opts == nil {
+			return
+		}
+		var base config.GenerationOptions
+		if r.Options != nil {
+			base = *r.Options
+		}
+		merged := base.Merge(opts)
+		r.Options = &merged
+	}
+}
+
+// WithModel sends the request to a model other than the active one.
+func WithModel(model string) RequestOption {
+	return func(r *ChatRequest) {
+		r.Model = model
+	}
+}
+
+func WithTools(tools []Tool) RequestOption {
+	return func(r *ChatRequest) {
+		r.Tools = tools
+	}
 }
 
 type EmbedRequest struct {
@@ -14,25 +69,61 @@ type EmbedRequest struct {
 }
 
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"`
+	// Images are base64-encoded pictures for vision-capable models.
+	Images []string `json:"images,omitempty"`
+}
+
+// Tool describes a function the model may call, in Ollama's format.
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+type ToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+type ToolCall struct {
+	Function struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	} `json:"function"`
 }
 
 type ChatResponse struct {
-	Model     string    `json:"model"`
-	CreatedAt time.Time `json:"created_at"`
-	Message   struct {
-		Role    string `json:"role"`
-		Content string `json:"content"`
-	} `json:"message"`
-	DoneReason         string `json:"done_reason"`
-	Done               bool   `json:"done"`
-	TotalDuration      int    `json:"total_duration"`
-	LoadDuration       int    `json:"load_duration"`
-	PromptEvalCount    int    `json:"prompt_eval_count"`
-	PromptEvalDuration int    `json:"prompt_eval_duration"`
-	EvalCount          int    `json:"eval_count"`
-	EvalDuration       int    `json:"eval_duration"`
+	Model              string    `json:"model"`
+	CreatedAt          time.Time `json:"created_at"`
+	Message            Message   `json:"message"`
+	DoneReason         string    `json:"done_reason"`
+	Done               bool      `json:"done"`
+	TotalDuration      int       `json:"total_duration"`
+	LoadDuration       int       `json:"load_duration"`
+	PromptEvalCount    int       `json:"prompt_eval_count"`
+	PromptEvalDuration int       `json:"prompt_eval_duration"`
+	EvalCount          int       `json:"eval_count"`
+	EvalDuration       int       `json:"eval_duration"`
+	Sources            []Source  `json:"sources,omitempty"`
+	// Options echoes the generation options the answer was produced with.
+	Options *config.GenerationOptions `json:"options,omitempty"`
+}
+
+// Source is a document chunk retrieved from the vector store and placed
+// into the prompt. The position fields locate it in the document for
+// citations.
+type Source struct {
+	DocumentID string `json:"document_id"`
+	Text       string `json:"text"`
+	ChunkIndex int    `json:"chunk_index"`
+	Start      int    `json:"start"`
+	End        int    `json:"end"`
+	Page       int    `json:"page,omitempty"`
+	Section    string `json:"section,omitempty"`
 }
 
 type EmbeddingResponse struct {
@@ -42,3 +133,59 @@ type EmbeddingResponse struct {
 	LoadDuration    int64       `json:"load_duration"`
 	PromptEvalCount int         `json:"prompt_eval_count"`
 }
+
+// ActiveModels are the models currently used for chat and embeddings.
+type ActiveModels struct {
+	Chat      string `json:"chat_model"`
+	Embedding string `json:"embedding_model"`
+}
+
+type ModelList struct {
+	Models []ModelSummary `json:"models"`
+}
+
+type ModelSummary struct {
+	Name       string       `json:"name"`
+	Model      string       `json:"model"`
+	ModifiedAt time.Time    `json:"modified_at,omitempty"`
+	Size       int64        `json:"size"`
+	SizeVRAM   int64        `json:"size_vram,omitempty"`
+	Digest     string       `json:"digest"`
+	ExpiresAt  time.Time    `json:"expires_at,omitempty"`
+	Details    ModelDetails `json:"details"`
+	Nodes      []string     `json:"nodes,omitempty"`
+}
+
+type ModelDetails struct {
+	Format            string   `json:"format"`
+	Family            string   `json:"family"`
+	Families          []string `json:"families"`
+	ParameterSize     string   `json:"parameter_size"`
+	QuantizationLevel string   `json:"quantization_level"`
+}
+
+type ModelInfo struct {
+	License      string         `json:"license,omitempty"`
+	Modelfile    string         `json:"modelfile,omitempty"`
+	Parameters   string         `json:"parameters,omitempty"`
+	Template     string         `json:"template,omitempty"`
+	Details      ModelDetails   `json:"details"`
+	ModelInfo    map[string]any `json:"model_info,omitempty"`
+	Capabilities []string       `json:"capabilities,omitempty"`
+	ModifiedAt   time.Time      `json:"modified_at,omitempty"`
+}
+
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Node      string `json:"node,omitempty"`
+}
+
+type modelRequest struct {
+	Model     string `json:"model"`
+	KeepAlive string `json:"keep_alive,omitempty"`
+	Stream    *bool  `json:"stream,omitempty"`
+}
@@ -0,0 +1,18 @@
+package ollama
+
+import (
+	"ai-service/internal/util/config"
+	"testing"
+)
+
+func TestWithOptions(t *testing.T) {
+	temperature, seed, numCtx := 0.1, 7, 2048
+	req := ChatRequest{}
+	WithOptions(&config.GenerationOptions{Temperature: &temperature, NumCtx: &numCtx})(&req)
+	WithOptions(&config.GenerationOptions{Seed: &seed})(&req)
+	WithOptions(nil)(&req)
+	opts := req.Options
+	if opts == nil || *opts.Temperature != 0.1 || *opts.NumCtx != 2048 || *opts.Seed != 7 {
+		t.Fatalf("options %+v, want the layers merged", opts)
+	}
+}
@@ -1,40 +1,47 @@
 package ollama
 
 import (
+	"ai-service/internal/repository/vector"
 	"context"
 	"encoding/json"
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
 	"github.com/pkg/errors"
 	"net/http"
+	"strings"
 )
 
-func (l *llmService) Answer(ctx context.Context, orgID string, docEmbeds []float32, messages []Message) (*ChatResponse, error) {
-	searchResult, err := l.repository.Vector.GetTopK(ctx, orgID, 5, docEmbeds)
+func (l *llmService) Answer(ctx context.Context, orgID string, docEmbeds []float32, messages []Message, opts ...RequestOption) (*ChatResponse, error) {
+	sources, err := l.Search(ctx, orgID, docEmbeds)
 	if err != nil {
 		return nil, err
 	}
-	var documents string
-	for _, result := range searchResult {
-		column := result.Fields.GetColumn("text")
-		for i := 0; i < column.Len(); i++ {
-			s, err := column.GetAsString(i)
-			if err != nil {
-				return nil, err
-			}
-			documents += s
-		}
+	return l.Generate(ctx, orgID, sources, messages, opts...)
+}
+
+// Search returns the chunks of the org's documents closest to docEmbeds.
+func (l *llmService) Search(ctx context.Context, orgID string, docEmbeds []float32) ([]Source, error) {
+	searchResult, err := l.repository.Vector.GetTopK(ctx, orgID, 5, docEmbeds)
+	if err != nil {
+		return nil, err
 	}
-	messages = append(messages, Message{
-		Role:    role,
-		Content: system_prompt + documents,
-	})
-	messages[len(messages)-1], messages[len(messages)-2] = messages[len(messages)-2], messages[len(messages)-1]
-	chatRequest := ChatRequest{
-		Model:    l.config.Ollama.Model,
-		Messages: messages,
-		Stream:   false,
+	return SourcesFromResults(searchResult)
+}
+
+// Generate answers messages using sources as the document context.
+func (l *llmService) Generate(ctx context.Context, orgID string, sources []Source, messages []Message, opts ...RequestOption) (*ChatResponse, error) {
+	chatResponse, err := l.Chat(ctx, prompt(sources, messages), l.withOrg(orgID, opts)...)
+	if err != nil {
+		return nil, err
 	}
-	url := l.config.Ollama.Url + l.config.Ollama.Endpoints[chat]
-	respBody, status, err := l.handler(http.MethodPost, url, chatRequest)
+	chatResponse.Sources = sources
+	return chatResponse, nil
+}
+
+// Chat sends messages to the configured model as they are, without
+// retrieval.
+func (l *llmService) Chat(ctx context.Context, messages []Message, opts ...RequestOption) (*ChatResponse, error) {
+	chatRequest := l.chatRequest(messages, false, opts)
+	respBody, status, err := l.handler(ctx, http.MethodPost, chat, chatRequest.Model, chatRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -46,21 +53,129 @@ func (l *llmService) Answer(ctx context.Context, orgID string, docEmbeds []float
 		if err != nil {
 			return nil, err
 		}
+		chatResponse.Options = chatRequest.effectiveOptions()
 		return &chatResponse, nil
 	default:
 		return nil, errors.New(string(respBody))
 	}
 }
 
-func (l *llmService) Embed(input []string) ([][][]float32, error) {
+// AnswerStream works like Answer but calls fn for every partial response
+// Ollama streams back. The final response (Done set) carries the sources.
+func (l *llmService) AnswerStream(ctx context.Context, orgID string, docEmbeds []float32, messages []Message, fn func(*ChatResponse) error, opts ...RequestOption) error {
+	sources, err := l.Search(ctx, orgID, docEmbeds)
+	if err != nil {
+		return err
+	}
+	return l.GenerateStream(ctx, orgID, sources, messages, fn, opts...)
+}
+
+// GenerateStream is the streaming form of Generate.
+func (l *llmService) GenerateStream(ctx context.Context, orgID string, sources []Source, messages []Message, fn func(*ChatResponse) error, opts ...RequestOption) error {
+	chatRequest := l.chatRequest(prompt(sources, messages), true, l.withOrg(orgID, opts))
+	return l.stream(ctx, chat, chatRequest.Model, chatRequest, func(line []byte) error {
+		var chatResponse ChatResponse
+		if err := json.Unmarshal(line, &chatResponse); err != nil {
+			return err
+		}
+		if chatResponse.Done {
+			chatResponse.Sources = sources
+			chatResponse.Options = chatRequest.effectiveOptions()
+		}
+		return fn(&chatResponse)
+	})
+}
+
+// chatRequest builds a request for the configured model with the default
+// generation options, then applies opts. keep_alive is a top-level field in
+// Ollama's API, so it is moved out of the options block.
+func (l *llmService) chatRequest(messages []Message, stream bool, opts []RequestOption) ChatRequest {
+	defaults := l.config.Ollama.Options
+	chatRequest := ChatRequest{
+		Model:    l.Models().Chat,
+		Messages: messages,
+		Stream:   stream,
+		Options:  &defaults,
+	}
+	for _, opt := range opts {
+		opt(&chatRequest)
+	}
+	if chatRequest.Options != nil && chatRequest.Options.KeepAlive != "" {
+		chatRequest.KeepAlive = chatRequest.Options.KeepAlive
+		options := *chatRequest.Options
+		options.KeepAlive = ""
+		chatRequest.Options = &options
+	}
+	return chatRequest
+}
+
+// withOrg puts the org's option overrides before the caller's options.
+func (l *llmService) withOrg(orgID string, opts []RequestOption) []RequestOption {
+	org := l.config.Orgs[orgID].Options
+	if org == nil {
+		return opts
+	}
+	return append([]RequestOption{WithOptions(org)}, opts...)
+}
+
+// prompt inserts sources as a system message right before the last user
+// message, each quoted in a <document> tag so it reads as data.
+func prompt(sources []Source, messages []Message) []Message {
+	var documents string
+	for _, source := range sources {
+		documents += "<document>\n" + documentTags.Replace(source.Text) + "\n</document>\n"
+	}
+	out := make([]Message, 0, len(messages)+1)
+	out = append(out, messages[:len(messages)-1]...)
+	out = append(out, Message{
+		Role:    role,
+		Content: system_prompt + documents,
+	}, messages[len(messages)-1])
+	return out
+}
+
+// documentTags keeps chunk text from closing or opening the quoting tags.
+var documentTags = strings.NewReplacer("<document", "&lt;document", "</document", "&lt;/document")
+
+// SourcesFromResults flattens vector search results into sources.
+func SourcesFromResults(searchResult []client.SearchResult) ([]Source, error) {
+	var sources []Source
+	for _, result := range searchResult {
+		column := result.Fields.GetColumn("text")
+		for i := 0; i < column.Len(); i++ {
+			s, err := column.GetAsString(i)
+			if err != nil {
+				return nil, err
+			}
+			meta := vector.ChunkMeta(result.Fields, i)
+			source := Source{
+				DocumentID: vector.DocumentID(result, i),
+				Text:       s,
+				ChunkIndex: meta.Index,
+				Start:      meta.Start,
+				End:        meta.End,
+				Page:       meta.Page,
+				Section:    meta.Section,
+			}
+			sources = append(sources, source)
+		}
+	}
+	return sources, nil
+}
+
+func (l *llmService) Embed(ctx context.Context, input []string) ([][][]float32, error) {
+	return l.embed(ctx, l.Models().Embedding, input)
+}
+
+func (l *llmService) embed(ctx context.Context, model string, input []string) ([][][]float32, error) {
+	ctx = WithPriority(ctx, priorityFrom(ctx, PriorityEmbedding))
 	var result [][][]float32
 	for _, text := range input {
 		req := EmbedRequest{
-			Model: l.config.Ollama.Model,
+			Model: model,
 			Input: text,
 		}
-		url := l.config.Ollama.Url + l.config.Ollama.Endpoints[embed]
-		respBody, status, err := l.handler(http.MethodPost, url, req)
+		respBody, status, err := l.handler(ctx, http.MethodPost, embed, model, req)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,227 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultHealthInterval = 15 * time.Second
+
+// NodeStatus is the view of one Ollama instance as last seen by the prober.
+type NodeStatus struct {
+	URL         string    `json:"url"`
+	Healthy     bool      `json:"healthy"`
+	Outstanding int64     `json:"outstanding"`
+	Installed   []string  `json:"installed"`
+	Loaded      []string  `json:"loaded"`
+	LastError   string    `json:"last_error,omitempty"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+type node struct {
+	url         string
+	outstanding atomic.Int64
+
+	mu        sync.RWMutex
+	healthy   bool
+	installed map[string]bool // nil until the first successful probe
+	loaded    map[string]bool
+	lastError string
+	checkedAt time.Time
+}
+
+type pool struct {
+	nodes []*node
+}
+
+func newPool(urls []string) *pool {
+	p := &pool{}
+	for _, u := range urls {
+		// Nodes count as healthy until the first probe says otherwise.
+		p.nodes = append(p.nodes, &node{url: strings.TrimRight(u, "/"), healthy: true})
+	}
+	return p
+}
+
+// pick returns the node to send a request for model to, skipping nodes in
+// tried. Healthy nodes that already have the model loaded come first, then
+// nodes that have it installed; ties go to the node with the fewest
+// requests in flight. Unhealthy nodes are only used when nothing else is left.
+func (p *pool) pick(model string, tried map[*node]bool) *node {
+	var best *node
+	bestTier, bestLoad := 0, int64(0)
+	for _, n := range p.nodes {
+		if tried[n] {
+			continue
+		}
+		tier, load := n.tier(model), n.outstanding.Load()
+		if best == nil || tier < bestTier || (tier == bestTier && load < bestLoad) {
+			best, bestTier, bestLoad = n, tier, load
+		}
+	}
+	return best
+}
+
+func (n *node) tier(model string) int {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	model = normalizeModel(model)
+	switch {
+	case !n.healthy:
+		return 4
+	case model == "" || n.installed == nil:
+		return 2
+	case n.loaded[model]:
+		return 0
+	case n.installed[model]:
+		return 1
+	default:
+		return 3
+	}
+}
+
+func (n *node) markDown(err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.healthy {
+		log.Printf("ollama node %s is down: %v", n.url, err)
+	}
+	n.healthy = false
+	n.lastError = err.Error()
+}
+
+func (n *node) markLoaded(model string) {
+	if model == "" {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.loaded == nil {
+		n.loaded = make(map[string]bool)
+	}
+	n.loaded[normalizeModel(model)] = true
+}
+
+func (n *node) status() NodeStatus {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return NodeStatus{
+		URL:         n.url,
+		Healthy:     n.healthy,
+		Outstanding: n.outstanding.Load(),
+		Installed:   keys(n.installed),
+		Loaded:      keys(n.loaded),
+		LastError:   n.lastError,
+		CheckedAt:   n.checkedAt,
+	}
+}
+
+// watch probes every node right away and then every interval until ctx is done.
+func (l *llmService) watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultHealthInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		var wg sync.WaitGroup
+		for _, n := range l.pool.nodes {
+			wg.Add(1)
+			go func(n *node) {
+				defer wg.Done()
+				l.probe(ctx, n)
+			}(n)
+		}
+		wg.Wait()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe refreshes health and the installed and loaded models of n.
+func (l *llmService) probe(ctx context.Context, n *node) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	installed, err := l.probeModels(ctx, n, tags)
+	var loaded map[string]bool
+	if err == nil {
+		loaded, err = l.probeModels(ctx, n, running)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.checkedAt = time.Now()
+	if err != nil {
+		if n.healthy {
+			log.Printf("ollama node %s failed health check: %v", n.url, err)
+		}
+		n.healthy = false
+		n.lastError = err.Error()
+		return
+	}
+	if !n.healthy {
+		log.Printf("ollama node %s is back up", n.url)
+	}
+	n.healthy = true
+	n.lastError = ""
+	n.installed = installed
+	n.loaded = loaded
+}
+
+func (l *llmService) probeModels(ctx context.Context, n *node, endpoint string) (map[string]bool, error) {
+	respBody, status, err := l.handlerOn(ctx, n, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", endpoint, status)
+	}
+	var list ModelList
+	if err := json.Unmarshal(respBody, &list); err != nil {
+		return nil, err
+	}
+	models := make(map[string]bool, len(list.Models))
+	for _, m := range list.Models {
+		models[normalizeModel(m.Name)] = true
+	}
+	return models, nil
+}
+
+func (l *llmService) Nodes() []NodeStatus {
+	statuses := make([]NodeStatus, 0, len(l.pool.nodes))
+	for _, n := range l.pool.nodes {
+		statuses = append(statuses, n.status())
+	}
+	return statuses
+}
+
+// normalizeModel adds the implicit ":latest" tag so "llama3" and
+// "llama3:latest" refer to the same model.
+func normalizeModel(model string) string {
+	if model != "" && !strings.Contains(model, ":") {
+		return model + ":latest"
+	}
+	return model
+}
+
+func keys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,84 @@
+package ollama
+
+import (
+	"errors"
+	"testing"
+)
+
+func testPool(t *testing.T) (*pool, map[string]*node) {
+	t.Helper()
+	p := newPool([]string{"http://a/", "http://b", "http://c"})
+	byURL := map[string]*node{}
+	for _, n := range p.nodes {
+		byURL[n.url] = n
+	}
+	if len(byURL) != 3 || byURL["http://a"] == nil {
+		t.Fatalf("nodes %v, want trailing slashes trimmed", byURL)
+	}
+	return p, byURL
+}
+
+func TestPoolPick(t *testing.T) {
+	p, nodes := testPool(t)
+	a, b, c := nodes["http://a"], nodes["http://b"], nodes["http://c"]
+	a.installed = map[string]bool{"llama3:latest": true}
+	b.installed = map[string]bool{"llama3:latest": true}
+	b.loaded = map[string]bool{"llama3:latest": true}
+	c.installed = map[string]bool{"qwen:7b": true}
+
+	if got := p.pick("llama3", nil); got != b {
+		t.Errorf("picked %s, want the node with the model loaded", got.url)
+	}
+	if got := p.pick("llama3", map[*node]bool{b: true}); got != a {
+		t.Errorf("picked %s, want the node with the model installed", got.url)
+	}
+	if got := p.pick("llama3", map[*node]bool{a: true, b: true}); got != c {
+		t.Errorf("picked %s, want the last node left", got.url)
+	}
+	if got := p.pick("llama3", map[*node]bool{a: true, b: true, c: true}); got != nil {
+		t.Errorf("picked %s, want nil when every node was tried", got.url)
+	}
+
+	b.markDown(errors.New("connection refused"))
+	if got := p.pick("llama3", nil); got != a {
+		t.Errorf("picked %s, want a healthy node over the one down", got.url)
+	}
+	if st := b.status(); st.Healthy || st.LastError != "connection refused" {
+		t.Errorf("status of the node down: %+v", st)
+	}
+	a.markDown(errors.New("timeout"))
+	c.markDown(errors.New("timeout"))
+	if got := p.pick("llama3", nil); got == nil {
+		t.Error("no node picked when all are down")
+	}
+}
+
+func TestPoolPickLeastLoaded(t *testing.T) {
+	p, nodes := testPool(t)
+	nodes["http://a"].outstanding.Store(3)
+	nodes["http://b"].outstanding.Store(1)
+	nodes["http://c"].outstanding.Store(2)
+	// Nothing probed yet: every node is equal but for its load.
+	if got := p.pick("llama3", nil); got != nodes["http://b"] {
+		t.Errorf("picked %s, want the least loaded node", got.url)
+	}
+
+	nodes["http://c"].markLoaded("llama3")
+	nodes["http://c"].installed = map[string]bool{"llama3:latest": true}
+	if got := p.pick("llama3:latest", nil); got != nodes["http://c"] {
+		t.Errorf("picked %s, want the node that loaded the model despite its load", got.url)
+	}
+}
+
+func TestNormalizeModel(t *testing.T) {
+	for in, want := range map[string]string{
+		"":              "",
+		"llama3":        "llama3:latest",
+		"llama3:latest": "llama3:latest",
+		"qwen2.5:7b":    "qwen2.5:7b",
+	} {
+		if got := normalizeModel(in); got != want {
+			t.Errorf("normalizeModel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
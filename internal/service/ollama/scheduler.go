@@ -0,0 +1,252 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxConcurrent = 4
+	defaultMaxQueue      = 100
+)
+
+// ErrQueueFull is returned when the scheduler sheds a request because too
+// many are already waiting.
+var ErrQueueFull = errors.New("llm queue is full, retry later")
+
+// ErrNoNode is returned when no Ollama node could take a request: the pool
+// is empty or every node failed to answer.
+var ErrNoNode = errors.New("no healthy Ollama node")
+
+// Priority is the scheduling class of an LLM call. Lower values are served first.
+type Priority int
+
+const (
+	PriorityInteractive Priority = iota
+	PriorityEmbedding
+	PriorityBackground
+	priorityCount
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityEmbedding:
+		return "embedding"
+	default:
+		return "background"
+	}
+}
+
+type priorityKey struct{}
+
+// WithPriority marks every LLM call made with ctx as belonging to class p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func priorityFrom(ctx context.Context, def Priority) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return def
+}
+
+// HTTPStatus maps LLM errors to the status a handler should answer with.
+func HTTPStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrQueueFull):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrNoNode):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+type SchedulerStats struct {
+	MaxConcurrent int                   `json:"max_concurrent"`
+	MaxQueue      int                   `json:"max_queue"`
+	Running       int                   `json:"running"`
+	Classes       map[string]ClassStats `json:"classes"`
+}
+
+type ClassStats struct {
+	Waiting    int   `json:"waiting"`
+	Admitted   int64 `json:"admitted"`
+	Rejected   int64 `json:"rejected"`
+	AvgWaitMs  int64 `json:"avg_wait_ms"`
+	MaxWaitMs  int64 `json:"max_wait_ms"`
+	LastWaitMs int64 `json:"last_wait_ms"`
+}
+
+type classCounters struct {
+	waiting  int
+	admitted int64
+	rejected int64
+	total    time.Duration
+	max      time.Duration
+	last     time.Duration
+}
+
+type waiter struct {
+	ready    chan error
+	enqueued time.Time
+}
+
+// scheduler bounds how many LLM calls run at once and serves waiting calls
+// strictly by priority, first come first served within a class.
+type scheduler struct {
+	mu       sync.Mutex
+	limit    int
+	maxQueue int
+	running  int
+	queued   int
+	queues   [priorityCount][]*waiter
+	stats    [priorityCount]classCounters
+}
+
+func newScheduler(limit, maxQueue int) *scheduler {
+	if limit <= 0 {
+		limit = defaultMaxConcurrent
+	}
+	if maxQueue <= 0 {
+		maxQueue = defaultMaxQueue
+	}
+	return &scheduler{limit: limit, maxQueue: maxQueue}
+}
+
+// acquire blocks until a slot is free for a call of class p. The returned
+// function must be called once the call is finished.
+func (s *scheduler) acquire(ctx context.Context, p Priority) (func(), error) {
+	s.mu.Lock()
+	if s.running < s.limit && s.queued == 0 {
+		s.running++
+		s.admit(p, 0)
+		s.mu.Unlock()
+		return s.release, nil
+	}
+	if s.queued >= s.maxQueue && !s.shed(p) {
+		s.stats[p].rejected++
+		s.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+	w := &waiter{ready: make(chan error, 1), enqueued: time.Now()}
+	s.queues[p] = append(s.queues[p], w)
+	s.queued++
+	s.stats[p].waiting++
+	s.mu.Unlock()
+
+	select {
+	case err := <-w.ready:
+		if err != nil {
+			return nil, err
+		}
+		return s.release, nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		if s.remove(p, w) {
+			s.mu.Unlock()
+			return nil, ctx.Err()
+		}
+		s.mu.Unlock()
+		// The slot was granted while we were giving up; hand it on.
+		if err := <-w.ready; err == nil {
+			s.release()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (s *scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	for p := Priority(0); p < priorityCount; p++ {
+		if len(s.queues[p]) == 0 {
+			continue
+		}
+		w := s.queues[p][0]
+		s.queues[p] = s.queues[p][1:]
+		s.queued--
+		s.stats[p].waiting--
+		s.running++
+		s.admit(p, time.Since(w.enqueued))
+		w.ready <- nil
+		return
+	}
+}
+
+// shed makes room for a call of class p by rejecting the newest waiter of
+// the lowest class below p. It reports whether room was made.
+func (s *scheduler) shed(p Priority) bool {
+	for low := priorityCount - 1; low > p; low-- {
+		n := len(s.queues[low])
+		if n == 0 {
+			continue
+		}
+		w := s.queues[low][n-1]
+		s.queues[low] = s.queues[low][:n-1]
+		s.queued--
+		s.stats[low].waiting--
+		s.stats[low].rejected++
+		w.ready <- ErrQueueFull
+		return true
+	}
+	return false
+}
+
+func (s *scheduler) remove(p Priority, w *waiter) bool {
+	for i, candidate := range s.queues[p] {
+		if candidate == w {
+			s.queues[p] = append(s.queues[p][:i], s.queues[p][i+1:]...)
+			s.queued--
+			s.stats[p].waiting--
+			return true
+		}
+	}
+	return false
+}
+
+func (s *scheduler) admit(p Priority, wait time.Duration) {
+	c := &s.stats[p]
+	c.admitted++
+	c.total += wait
+	c.last = wait
+	if wait > c.max {
+		c.max = wait
+	}
+}
+
+func (s *scheduler) snapshot() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := SchedulerStats{
+		MaxConcurrent: s.limit,
+		MaxQueue:      s.maxQueue,
+		Running:       s.running,
+		Classes:       make(map[string]ClassStats, priorityCount),
+	}
+	for p := Priority(0); p < priorityCount; p++ {
+		c := s.stats[p]
+		st := ClassStats{
+			Waiting:    c.waiting,
+			Admitted:   c.admitted,
+			Rejected:   c.rejected,
+			MaxWaitMs:  c.max.Milliseconds(),
+			LastWaitMs: c.last.Milliseconds(),
+		}
+		if c.admitted > 0 {
+			st.AvgWaitMs = (c.total / time.Duration(c.admitted)).Milliseconds()
+		}
+		out.Classes[p.String()] = st
+	}
+	return out
+}
+
+func (l *llmService) SchedulerStats() SchedulerStats {
+	return l.scheduler.snapshot()
+}
@@ -0,0 +1,161 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitQueued waits until n calls wait in the scheduler.
+func waitQueued(t *testing.T, s *scheduler, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mu.Lock()
+		queued := s.queued
+		s.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d calls queued, want %d", queued, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type result struct {
+	name string
+	err  error
+}
+
+// enqueue starts a call of class p that reports on out once admitted or
+// refused and releases its slot right away.
+func enqueue(t *testing.T, s *scheduler, p Priority, name string, out chan<- result) {
+	t.Helper()
+	s.mu.Lock()
+	queued := s.queued
+	s.mu.Unlock()
+	go func() {
+		release, err := s.acquire(context.Background(), p)
+		out <- result{name, err}
+		if err == nil {
+			release()
+		}
+	}()
+	waitQueued(t, s, queued+1)
+}
+
+func receive(t *testing.T, out <-chan result) result {
+	t.Helper()
+	select {
+	case r := <-out:
+		return r
+	case <-time.After(time.Second):
+		t.Fatal("no call was admitted")
+		return result{}
+	}
+}
+
+func TestSchedulerServesByPriority(t *testing.T) {
+	s := newScheduler(1, 10)
+	release, err := s.acquire(context.Background(), PriorityInteractive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan result, 5)
+	enqueue(t, s, PriorityBackground, "background 1", out)
+	enqueue(t, s, PriorityEmbedding, "embedding", out)
+	enqueue(t, s, PriorityBackground, "background 2", out)
+	enqueue(t, s, PriorityInteractive, "interactive 1", out)
+	enqueue(t, s, PriorityInteractive, "interactive 2", out)
+	release()
+
+	want := []string{"interactive 1", "interactive 2", "embedding", "background 1", "background 2"}
+	for _, name := range want {
+		r := receive(t, out)
+		if r.err != nil {
+			t.Fatalf("%s: %v", r.name, r.err)
+		}
+		if r.name != name {
+			t.Fatalf("admitted %s, want %s", r.name, name)
+		}
+	}
+	if st := s.snapshot(); st.Running != 0 || st.Classes["background"].Admitted != 2 {
+		t.Errorf("stats after the run: %+v", st)
+	}
+}
+
+func TestSchedulerAdmitsAtOnceBelowLimit(t *testing.T) {
+	s := newScheduler(2, 10)
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		_, err := s.acquire(ctx, PriorityBackground)
+		cancel()
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.acquire(ctx, PriorityInteractive); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("third call: %v, want a timeout", err)
+	}
+	if st := s.snapshot(); st.Classes["interactive"].Waiting != 0 {
+		t.Errorf("timed out call still waits: %+v", st.Classes["interactive"])
+	}
+}
+
+func TestSchedulerShedsLowerClasses(t *testing.T) {
+	s := newScheduler(1, 2)
+	release, err := s.acquire(context.Background(), PriorityInteractive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan result, 4)
+	enqueue(t, s, PriorityBackground, "background 1", out)
+	enqueue(t, s, PriorityBackground, "background 2", out)
+
+	// The queue is full: an interactive call pushes out the newest
+	// background one.
+	go func() {
+		release, err := s.acquire(context.Background(), PriorityInteractive)
+		out <- result{"interactive", err}
+		if err == nil {
+			release()
+		}
+	}()
+	if r := receive(t, out); r.name != "background 2" || !errors.Is(r.err, ErrQueueFull) {
+		t.Fatalf("got %s: %v, want background 2 shed", r.name, r.err)
+	}
+	waitQueued(t, s, 2)
+
+	// Nothing below background is left to shed.
+	if _, err := s.acquire(context.Background(), PriorityBackground); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("background call on a full queue: %v, want ErrQueueFull", err)
+	}
+	if got := HTTPStatus(ErrQueueFull); got != 429 {
+		t.Errorf("HTTPStatus(ErrQueueFull) = %d, want 429", got)
+	}
+
+	release()
+	for _, name := range []string{"interactive", "background 1"} {
+		if r := receive(t, out); r.name != name || r.err != nil {
+			t.Fatalf("got %s: %v, want %s admitted", r.name, r.err, name)
+		}
+	}
+	if st := s.snapshot(); st.Classes["background"].Rejected != 2 || st.Classes["interactive"].Rejected != 0 {
+		t.Errorf("rejections: %+v", st.Classes)
+	}
+}
+
+func TestPriorityFromContext(t *testing.T) {
+	ctx := context.Background()
+	if p := priorityFrom(ctx, PriorityEmbedding); p != PriorityEmbedding {
+		t.Errorf("default priority %v, want embedding", p)
+	}
+	if p := priorityFrom(WithPriority(ctx, PriorityBackground), PriorityInteractive); p != PriorityBackground {
+		t.Errorf("priority %v, want background", p)
+	}
+}
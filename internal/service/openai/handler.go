@@ -0,0 +1,246 @@
+package openai
+
+import (
+	"ai-service/internal/service/guardrails"
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/middleware"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+const finishStop = "stop"
+
+// ChatCompletions
+//
+// @Description OpenAI-compatible chat completions with retrieval over the user's documents
+// @Summary	Chat completions (OpenAI API)
+// @Tags openai
+// @Accept json
+// @Produce	json
+// @Param		request	body		ChatCompletionRequest	true	"body param"
+// @Success	200				{object}		ChatCompletionResponse
+// @Router /v1/chat/completions 	[post]
+func (o *openAIService) ChatCompletions(c echo.Context) error {
+	ctx := ollama.WithPriority(c.Request().Context(), ollama.PriorityInteractive)
+	uid, ok := middleware.UserIDFromContext(c)
+	if !ok {
+		return apiError(c, http.StatusUnauthorized, "authentication_error", "user not found")
+	}
+
+	var dataReq ChatCompletionRequest
+	if err := c.Bind(&dataReq); err != nil {
+		return apiError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+	}
+	if err := c.Validate(&dataReq); err != nil {
+		return apiError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+	}
+	if rsp := unknownModel(dataReq.Model, o.llm.Models().Chat); rsp != nil {
+		return c.JSON(http.StatusNotFound, rsp)
+	}
+	options := o.config.GenerationOptions(uid).Merge(dataReq.Options())
+	if err := options.Validate(); err != nil {
+		return apiError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+	}
+	withOptions := ollama.WithOptions(&options)
+
+	messages := make([]ollama.Message, 0, len(dataReq.Messages))
+	question := ""
+	for _, m := range dataReq.Messages {
+		messages = append(messages, ollama.Message{Role: m.Role, Content: string(m.Content)})
+		if m.Role == "user" {
+			question = string(m.Content)
+		}
+	}
+	if question == "" {
+		return apiError(c, http.StatusBadRequest, "invalid_request_error", "messages must contain a user message")
+	}
+
+	if err := o.guard.CheckMessages(ctx, uid, messages); err != nil {
+		var blocked *guardrails.BlockedError
+		if errors.As(err, &blocked) {
+			return apiError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		}
+		return llmError(c, err)
+	}
+
+	embeddings, err := o.llm.Embed(ctx, []string{question})
+	if err != nil {
+		return llmError(c, err)
+	}
+	sources, err := o.llm.Search(ctx, uid, embeddings[0][0])
+	if err != nil {
+		return llmError(c, err)
+	}
+	sources, err = o.guard.Sources(ctx, uid, sources)
+	if err != nil {
+		return llmError(c, err)
+	}
+
+	id := "chatcmpl-" + uuid.New().String()
+	created := time.Now().Unix()
+	if !dataReq.Stream {
+		response, err := o.llm.Generate(ctx, uid, sources, messages, withOptions)
+		if err != nil {
+			return llmError(c, err)
+		}
+		finish := finishStop
+		return c.JSON(http.StatusOK, ChatCompletionResponse{
+			ID:      id,
+			Object:  "chat.completion",
+			Created: created,
+			Model:   response.Model,
+			Choices: []Choice{{
+				Message:      &OutputMessage{Role: response.Message.Role, Content: response.Message.Content},
+				FinishReason: &finish,
+			}},
+			Usage: usage(response),
+		})
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+
+	first := true
+	err = o.llm.GenerateStream(ctx, uid, sources, messages, func(chunk *ollama.ChatResponse) error {
+		choice := Choice{Delta: &OutputMessage{Content: chunk.Message.Content}}
+		if first {
+			choice.Delta.Role = "assistant"
+			first = false
+		}
+		out := ChatCompletionResponse{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   chunk.Model,
+			Choices: []Choice{choice},
+		}
+		if chunk.Done {
+			finish := finishStop
+			out.Choices[0].FinishReason = &finish
+			out.Usage = usage(chunk)
+		}
+		return writeEvent(res, out)
+	}, withOptions)
+	if err != nil {
+		// Headers are already sent, so the error goes out as an event.
+		return writeEvent(res, ErrorResponse{Error: ErrorBody{Message: err.Error(), Type: "api_error"}})
+	}
+	_, err = fmt.Fprint(res, "data: [DONE]\n\n")
+	res.Flush()
+	return err
+}
+
+// Embeddings
+//
+// @Description OpenAI-compatible embeddings
+// @Summary	Embeddings (OpenAI API)
+// @Tags openai
+// @Accept json
+// @Produce	json
+// @Param		request	body		EmbeddingRequest	true	"body param"
+// @Success	200				{object}		EmbeddingResponse
+// @Router /v1/embeddings 	[post]
+func (o *openAIService) Embeddings(c echo.Context) error {
+	var dataReq EmbeddingRequest
+	if err := c.Bind(&dataReq); err != nil {
+		return apiError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+	}
+	if err := c.Validate(&dataReq); err != nil {
+		return apiError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+	}
+	if rsp := unknownModel(dataReq.Model, o.llm.Models().Embedding); rsp != nil {
+		return c.JSON(http.StatusNotFound, rsp)
+	}
+
+	embeddings, err := o.llm.Embed(c.Request().Context(), dataReq.Input)
+	if err != nil {
+		return llmError(c, err)
+	}
+	response := EmbeddingResponse{
+		Object: "list",
+		Model:  o.llm.Models().Embedding,
+		Data:   make([]Embedding, 0, len(embeddings)),
+	}
+	for i, embedding := range embeddings {
+		item := Embedding{Object: "embedding", Index: i}
+		if len(embedding) > 0 {
+			item.Embedding = embedding[0]
+		}
+		response.Data = append(response.Data, item)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// Models
+//
+// @Description OpenAI-compatible model list
+// @Summary	List models (OpenAI API)
+// @Tags openai
+// @Produce	json
+// @Success	200				{object}		ModelList
+// @Router /v1/models 	[get]
+func (o *openAIService) Models(c echo.Context) error {
+	return c.JSON(http.StatusOK, ModelList{
+		Object: "list",
+		Data: []Model{{
+			ID:      o.llm.Models().Chat,
+			Object:  "model",
+			OwnedBy: o.config.Name,
+		}},
+	})
+}
+
+// unknownModel returns the OpenAI error for a request that names a model
+// other than the served one, or nil. An empty model means the served one.
+func unknownModel(model, served string) *ErrorResponse {
+	if model == "" || model == served {
+		return nil
+	}
+	return &ErrorResponse{Error: ErrorBody{
+		Message: fmt.Sprintf("the model %q does not exist, use %q", model, served),
+		Type:    "invalid_request_error",
+		Code:    "model_not_found",
+	}}
+}
+
+func usage(response *ollama.ChatResponse) *Usage {
+	return &Usage{
+		PromptTokens:     response.PromptEvalCount,
+		CompletionTokens: response.EvalCount,
+		TotalTokens:      response.PromptEvalCount + response.EvalCount,
+	}
+}
+
+func writeEvent(res *echo.Response, event any) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(res, "data: %s\n\n", body); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
+
+func apiError(c echo.Context, status int, kind, msg string) error {
+	return c.JSON(status, ErrorResponse{Error: ErrorBody{Message: msg, Type: kind}})
+}
+
+func llmError(c echo.Context, err error) error {
+	switch ollama.HTTPStatus(err) {
+	case http.StatusTooManyRequests:
+		return apiError(c, http.StatusTooManyRequests, "rate_limit_error", err.Error())
+	case http.StatusServiceUnavailable:
+		return apiError(c, http.StatusServiceUnavailable, "server_error", err.Error())
+	}
+	return apiError(c, http.StatusBadGateway, "api_error", err.Error())
+}
@@ -0,0 +1,186 @@
+package openai
+
+import (
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/config"
+	"ai-service/internal/util/middleware"
+	"ai-service/internal/util/validator"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// fakeLLM answers every question with "Ответ." and records the request
+// the answer was generated from.
+type fakeLLM struct {
+	ollama.LLMService
+	request ollama.ChatRequest
+}
+
+func (f *fakeLLM) Models() ollama.ActiveModels {
+	return ollama.ActiveModels{Chat: "llama3", Embedding: "bge-m3"}
+}
+
+func (f *fakeLLM) Embed(_ context.Context, input []string) ([][][]float32, error) {
+	out := make([][][]float32, len(input))
+	for i := range input {
+		out[i] = [][]float32{{float32(i), 1}}
+	}
+	return out, nil
+}
+
+func (f *fakeLLM) Search(context.Context, string, []float32) ([]ollama.Source, error) {
+	return nil, nil
+}
+
+func (f *fakeLLM) Generate(_ context.Context, _ string, _ []ollama.Source, messages []ollama.Message, opts ...ollama.RequestOption) (*ollama.ChatResponse, error) {
+	f.request = ollama.ChatRequest{Messages: messages}
+	for _, opt := range opts {
+		opt(&f.request)
+	}
+	return &ollama.ChatResponse{Model: "llama3", Message: ollama.Message{Role: "assistant", Content: "Ответ."}, Done: true, PromptEvalCount: 10, EvalCount: 3}, nil
+}
+
+func (f *fakeLLM) GenerateStream(ctx context.Context, orgID string, sources []ollama.Source, messages []ollama.Message, fn func(*ollama.ChatResponse) error, opts ...ollama.RequestOption) error {
+	if _, err := f.Generate(ctx, orgID, sources, messages, opts...); err != nil {
+		return err
+	}
+	for _, piece := range []string{"Отв", "ет."} {
+		if err := fn(&ollama.ChatResponse{Model: "llama3", Message: ollama.Message{Content: piece}}); err != nil {
+			return err
+		}
+	}
+	return fn(&ollama.ChatResponse{Model: "llama3", Done: true, EvalCount: 2})
+}
+
+func call(t *testing.T, cfg *config.Config, llm *fakeLLM, handler func(OpenAIService, echo.Context) error, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	e := echo.New()
+	e.Validator = validator.New()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(middleware.UserIDContextKey, "user")
+	if err := handler(NewOpenAIService(cfg, llm, nil), c); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func chatCompletions(s OpenAIService, c echo.Context) error { return s.ChatCompletions(c) }
+func embeddings(s OpenAIService, c echo.Context) error      { return s.Embeddings(c) }
+
+func ptr[T any](v T) *T { return &v }
+
+func TestChatCompletionsOptions(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Ollama.Options = config.GenerationOptions{Temperature: ptr(0.8), NumCtx: ptr(4096)}
+	llm := &fakeLLM{}
+	rec := call(t, cfg, llm, chatCompletions, `{
+		"model": "llama3",
+		"messages": [{"role": "system", "content": "Будь краток."}, {"role": "user", "content": [{"type": "text", "text": "Вопрос?"}]}],
+		"temperature": 0.2, "max_tokens": 100, "max_completion_tokens": 50, "stop": "END", "seed": 7
+	}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	want := config.GenerationOptions{Temperature: ptr(0.2), NumCtx: ptr(4096), NumPredict: ptr(50), Seed: ptr(7), Stop: []string{"END"}}
+	if got := llm.request.Options; got == nil || !reflect.DeepEqual(*got, want) {
+		t.Errorf("options %+v, want %+v", got, want)
+	}
+	if got := llm.request.Messages[1].Content; got != "Вопрос?" {
+		t.Errorf("user message %q", got)
+	}
+
+	var response ChatCompletionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Object != "chat.completion" || len(response.Choices) != 1 || response.Choices[0].Message.Content != "Ответ." {
+		t.Errorf("response %+v", response)
+	}
+	if response.Usage == nil || response.Usage.TotalTokens != 13 {
+		t.Errorf("usage %+v, want 13 tokens in total", response.Usage)
+	}
+}
+
+func TestChatCompletionsErrors(t *testing.T) {
+	tests := []struct {
+		name, body string
+		status     int
+		code       string
+	}{
+		{"unknown model", `{"model": "gpt-4", "messages": [{"role": "user", "content": "Hi"}]}`, http.StatusNotFound, "model_not_found"},
+		{"temperature out of range", `{"messages": [{"role": "user", "content": "Hi"}], "temperature": 3}`, http.StatusBadRequest, ""},
+		{"no messages", `{"messages": []}`, http.StatusBadRequest, ""},
+		{"no user message", `{"messages": [{"role": "system", "content": "Hi"}]}`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := call(t, &config.Config{}, &fakeLLM{}, chatCompletions, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			var response ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Error.Message == "" || response.Error.Code != tt.code {
+				t.Errorf("error %+v, want code %q", response.Error, tt.code)
+			}
+		})
+	}
+}
+
+func TestChatCompletionsStream(t *testing.T) {
+	rec := call(t, &config.Config{}, &fakeLLM{}, chatCompletions,
+		`{"stream": true, "messages": [{"role": "user", "content": "Вопрос?"}]}`)
+	body := rec.Body.String()
+	if !strings.HasSuffix(body, "data: [DONE]\n\n") {
+		t.Fatalf("stream does not end with [DONE]: %q", body)
+	}
+	var text strings.Builder
+	var last ChatCompletionResponse
+	for _, event := range strings.Split(strings.TrimSuffix(body, "data: [DONE]\n\n"), "\n\n") {
+		if event == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(event, "data: ")), &last); err != nil {
+			t.Fatalf("event %q: %v", event, err)
+		}
+		text.WriteString(last.Choices[0].Delta.Content)
+	}
+	if text.String() != "Ответ." {
+		t.Errorf("streamed %q", text.String())
+	}
+	if last.Choices[0].FinishReason == nil || *last.Choices[0].FinishReason != finishStop || last.Usage == nil {
+		t.Errorf("last event %+v, want the finish reason and usage", last)
+	}
+}
+
+func TestEmbeddings(t *testing.T) {
+	rec := call(t, &config.Config{}, &fakeLLM{}, embeddings, `{"model": "bge-m3", "input": ["a", "b"]}`)
+	var response EmbeddingResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || len(response.Data) != 2 || response.Data[1].Index != 1 || response.Model != "bge-m3" {
+		t.Errorf("status %d, response %+v", rec.Code, response)
+	}
+
+	rec = call(t, &config.Config{}, &fakeLLM{}, embeddings, `{"input": "a"}`)
+	if rec.Code != http.StatusOK {
+		t.Errorf("a single string input: status %d", rec.Code)
+	}
+	rec = call(t, &config.Config{}, &fakeLLM{}, embeddings, `{"model": "text-embedding-3-small", "input": "a"}`)
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "model_not_found") {
+		t.Errorf("unknown model: status %d, %s", rec.Code, rec.Body)
+	}
+}
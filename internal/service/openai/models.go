@@ -0,0 +1,154 @@
+package openai
+
+import (
+	"ai-service/internal/util/config"
+	"encoding/json"
+	"strings"
+)
+
+type ChatCompletionRequest struct {
+	Model    string        `json:"model"`
+	Messages []ChatMessage `json:"messages" validate:"required,min=1"`
+	Stream   bool          `json:"stream"`
+	User     string        `json:"user,omitempty"`
+
+	Temperature         *float64 `json:"temperature,omitempty"`
+	TopP                *float64 `json:"top_p,omitempty"`
+	MaxTokens           *int     `json:"max_tokens,omitempty"`
+	MaxCompletionTokens *int     `json:"max_completion_tokens,omitempty"`
+	Stop                Input    `json:"stop,omitempty"`
+	Seed                *int     `json:"seed,omitempty"`
+}
+
+// Options maps the sampling parameters onto generation options. Fields
+// the client left out stay nil, so the org defaults apply.
+func (r *ChatCompletionRequest) Options() *config.GenerationOptions {
+	opts := &config.GenerationOptions{
+		Temperature: r.Temperature,
+		TopP:        r.TopP,
+		NumPredict:  r.MaxTokens,
+		Seed:        r.Seed,
+		Stop:        r.Stop,
+	}
+	if r.MaxCompletionTokens != nil {
+		opts.NumPredict = r.MaxCompletionTokens
+	}
+	return opts
+}
+
+type ChatMessage struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+// Content accepts both the plain string form and the array-of-parts form
+// of an OpenAI message; only text parts are kept.
+type Content string
+
+func (c *Content) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*c = Content(s)
+		return nil
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return err
+	}
+	var texts []string
+	for _, part := range parts {
+		if part.Type == "text" {
+			texts = append(texts, part.Text)
+		}
+	}
+	*c = Content(strings.Join(texts, "\n"))
+	return nil
+}
+
+type ChatCompletionResponse struct {
+	ID      string   `json:"id"`
+	Object  string   `json:"object"`
+	Created int64    `json:"created"`
+	Model   string   `json:"model"`
+	Choices []Choice `json:"choices"`
+	Usage   *Usage   `json:"usage,omitempty"`
+}
+
+type Choice struct {
+	Index        int            `json:"index"`
+	Message      *OutputMessage `json:"message,omitempty"`
+	Delta        *OutputMessage `json:"delta,omitempty"`
+	FinishReason *string        `json:"finish_reason"`
+}
+
+type OutputMessage struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content"`
+}
+
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens,omitempty"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type EmbeddingRequest struct {
+	Model string `json:"model"`
+	Input Input  `json:"input" validate:"required,min=1"`
+}
+
+// Input accepts a single string or an array of strings. It also reads
+// the stop parameter, which has the same two forms.
+type Input []string
+
+func (in *Input) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*in = Input{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*in = list
+	return nil
+}
+
+type EmbeddingResponse struct {
+	Object string      `json:"object"`
+	Data   []Embedding `json:"data"`
+	Model  string      `json:"model"`
+	Usage  Usage       `json:"usage"`
+}
+
+type Embedding struct {
+	Object    string    `json:"object"`
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
+
+type ModelList struct {
+	Object string  `json:"object"`
+	Data   []Model `json:"data"`
+}
+
+type Model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+}
@@ -0,0 +1,30 @@
+package openai
+
+import (
+	"ai-service/internal/service/guardrails"
+	"ai-service/internal/service/ollama"
+	"ai-service/internal/util/config"
+	"github.com/labstack/echo/v4"
+)
+
+// OpenAIService exposes the RAG pipeline through the subset of the OpenAI
+// REST API that common clients (IDE plugins, LangChain, Open WebUI) rely on.
+type OpenAIService interface {
+	ChatCompletions(c echo.Context) error
+	Embeddings(c echo.Context) error
+	Models(c echo.Context) error
+}
+
+type openAIService struct {
+	config *config.Config
+	llm    ollama.LLMService
+	guard  *guardrails.Guard
+}
+
+func NewOpenAIService(cfg *config.Config, llm ollama.LLMService, guard *guardrails.Guard) OpenAIService {
+	return &openAIService{
+		config: cfg,
+		llm:    llm,
+		guard:  guard,
+	}
+}
@@ -0,0 +1,97 @@
+package redaction
+
+import (
+	"regexp"
+)
+
+const (
+	KindPhone = "phone"
+	KindIIN   = "iin"
+	KindCard  = "card"
+	KindEmail = "email"
+)
+
+// detector finds one kind of personal data. valid, when set, filters out
+// pattern matches that fail a checksum.
+type detector struct {
+	kind  string
+	re    *regexp.Regexp
+	valid func(string) bool
+}
+
+// detectors are tried in this order; earlier ones win on overlapping
+// matches, so cards and IINs are not mistaken for phone numbers.
+var detectors = []detector{
+	{
+		kind: KindEmail,
+		re:   regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	},
+	{
+		kind:  KindCard,
+		re:    regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		valid: luhn,
+	},
+	{
+		kind:  KindIIN,
+		re:    regexp.MustCompile(`\b\d{12}\b`),
+		valid: validIIN,
+	},
+	{
+		kind: KindPhone,
+		re:   regexp.MustCompile(`(?:\+7|\b8)[\s(-]*\d{3}[\s)-]*\d{3}[\s-]*\d{2}[\s-]*\d{2}\b|\+\d{1,3}[\s(-]*\d{2,4}[\s)-]*\d{3}[\s-]*\d{2,4}(?:[\s-]*\d{2,4})?\b`),
+	},
+}
+
+func digits(s string) []int {
+	out := make([]int, 0, len(s))
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			out = append(out, int(r-'0'))
+		}
+	}
+	return out
+}
+
+// luhn checks the card number checksum.
+func luhn(s string) bool {
+	d := digits(s)
+	if len(d) < 13 || len(d) > 19 {
+		return false
+	}
+	sum := 0
+	for i := len(d) - 1; i >= 0; i-- {
+		n := d[i]
+		if (len(d)-1-i)%2 == 1 {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+	}
+	return sum%10 == 0
+}
+
+// validIIN checks the control digit of a Kazakhstan individual
+// identification number.
+func validIIN(s string) bool {
+	d := digits(s)
+	if len(d) != 12 {
+		return false
+	}
+	control := func(weights []int) int {
+		sum := 0
+		for i, w := range weights {
+			sum += d[i] * w
+		}
+		return sum % 11
+	}
+	c := control([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11})
+	if c == 10 {
+		c = control([]int{3, 4, 5, 6, 7, 8, 9, 10, 11, 1, 2})
+		if c == 10 {
+			return false
+		}
+	}
+	return c == d[11]
+}
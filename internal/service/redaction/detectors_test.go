@@ -0,0 +1,181 @@
+package redaction
+
+import (
+	"ai-service/internal/util/config"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLuhn(t *testing.T) {
+	tests := []struct {
+		number string
+		valid  bool
+	}{
+		{"4111111111111111", true},
+		{"4111 1111 1111 1111", true},
+		{"5500-0000-0000-0004", true},
+		{"378282246310005", true},
+		{"6011000990139424", true},
+		{"4111111111111112", false},
+		{"4111 1111 1111 1121", false},
+		{"5500000000000005", false},
+		{"411111111111", false},
+		{"41111111111111111111", false},
+	}
+	for _, tt := range tests {
+		if got := luhn(tt.number); got != tt.valid {
+			t.Errorf("luhn(%q) = %v, want %v", tt.number, got, tt.valid)
+		}
+	}
+}
+
+func TestValidIIN(t *testing.T) {
+	tests := []struct {
+		iin   string
+		valid bool
+	}{
+		{"900101300017", true},
+		{"850715400129", true},
+		// The first weights give 10, the control digit comes from the second.
+		{"900000001802", true},
+		{"900101300018", false},
+		{"850715400120", false},
+		{"900000001800", false},
+		// Both weight sets give 10: no control digit fits.
+		{"100000000280", false},
+		{"100000000289", false},
+		{"90010130001", false},
+		{"9001013000170", false},
+	}
+	for _, tt := range tests {
+		if got := validIIN(tt.iin); got != tt.valid {
+			t.Errorf("validIIN(%q) = %v, want %v", tt.iin, got, tt.valid)
+		}
+	}
+}
+
+func session(t *testing.T, kinds ...string) *Session {
+	t.Helper()
+	return orgSession(t, "org", kinds...)
+}
+
+func orgSession(t *testing.T, org string, kinds ...string) *Session {
+	t.Helper()
+	r, err := NewRedactor(&config.Config{JWTSecret: "secret", Redaction: config.Redaction{Enabled: true, Detectors: kinds}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := sessionFrom(r.Begin(context.Background(), org))
+	if s == nil {
+		t.Fatal("no session while redaction is enabled")
+	}
+	return s
+}
+
+var placeholderNumber = regexp.MustCompile(`_\d+\]`)
+
+// shape drops the numbers of placeholders, which are hashes.
+func shape(text string) string {
+	return placeholderNumber.ReplaceAllString(text, "]")
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"card", "Карта 4111 1111 1111 1111.", "Карта [CARD]."},
+		{"card failing luhn", "Карта 4111 1111 1111 1112.", "Карта 4111 1111 1111 1112."},
+		{"iin", "ИИН 900101300017", "ИИН [IIN]"},
+		{"iin failing control digit", "Заказ 900101300018", "Заказ 900101300018"},
+		{"phone", "Звоните +7 (701) 123-45-67 или 8 701 765 43 21", "Звоните [PHONE] или [PHONE]"},
+		{"email", "Пишите на ivan.petrov@mail.kz", "Пишите на [EMAIL]"},
+		{"all kinds", "4111111111111111, 850715400129, +7 701 123 45 67, a@b.kz",
+			"[CARD], [IIN], [PHONE], [EMAIL]"},
+		{"nothing", "Сумма 1200 тенге", "Сумма 1200 тенге"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := session(t)
+			got := s.Redact(tt.in)
+			if shape(got) != tt.want {
+				t.Fatalf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if back := s.Restore(got); back != tt.in {
+				t.Errorf("Restore(%q) = %q, want %q", got, back, tt.in)
+			}
+		})
+	}
+}
+
+func TestRedactConfiguredDetectors(t *testing.T) {
+	s := session(t, KindEmail)
+	in := "a@b.kz, 4111111111111111"
+	if got, want := s.Redact(in), "[EMAIL], 4111111111111111"; shape(got) != want {
+		t.Errorf("Redact(%q) = %q, want %q", in, got, want)
+	}
+	if _, err := NewRedactor(&config.Config{Redaction: config.Redaction{Detectors: []string{"passport"}}}); err == nil {
+		t.Error("unknown detector accepted")
+	}
+}
+
+func TestPlaceholdersAreStablePerOrg(t *testing.T) {
+	first, second, other := session(t), session(t), orgSession(t, "other")
+	a := first.Redact("Телефон +7 701 123 45 67, почта Ivan@Mail.kz")
+	b := second.Redact("почта ivan@mail.kz, телефон 8 (701) 123-45-67")
+	inA, inB := placeholder.FindAllString(a, -1), placeholder.FindAllString(b, -1)
+	if len(inA) != 2 || len(inB) != 2 || inA[0] != inB[1] || inA[1] != inB[0] {
+		t.Errorf("sessions of one org disagree: %q and %q", a, b)
+	}
+	email := inA[1]
+	if first.Redact("ivan@mail.kz") != email {
+		t.Error("spellings of an email get different placeholders")
+	}
+	if got := first.Restore(email); got != "Ivan@Mail.kz" {
+		t.Errorf("restored %q, want the spelling seen first", got)
+	}
+	if other.Redact("ivan@mail.kz") == email {
+		t.Error("an email gets the same placeholder in different orgs")
+	}
+}
+
+func TestPlaceholderCollision(t *testing.T) {
+	s := session(t)
+	p := s.Redact("ivan@mail.kz")
+	// Pretend another value took the number first.
+	s.reverse[p] = "taken@mail.kz"
+	clear(s.forward)
+	q := s.Redact("ivan@mail.kz")
+	if q == p {
+		t.Fatalf("placeholder %s given to two values", p)
+	}
+	if got := s.Restore(p + " " + q); got != "taken@mail.kz ivan@mail.kz" {
+		t.Errorf("restored %q", got)
+	}
+}
+
+func TestRedactionDisabled(t *testing.T) {
+	r, err := NewRedactor(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := sessionFrom(r.Begin(context.Background(), "org"))
+	if got := s.Redact("a@b.kz"); got != "a@b.kz" {
+		t.Errorf("disabled redaction changed the text to %q", got)
+	}
+}
+
+func TestRestorerSplitPlaceholder(t *testing.T) {
+	s := session(t)
+	p := s.Redact("ivan@mail.kz")
+	r := &restorer{session: s}
+	var out strings.Builder
+	for _, piece := range []string{"Адрес: " + p[:4], p[4:7], p[7:] + ", ", "[неизвестно"} {
+		out.WriteString(r.push(piece, false))
+	}
+	out.WriteString(r.push("]", true))
+	if got, want := out.String(), "Адрес: ivan@mail.kz, [неизвестно]"; got != want {
+		t.Errorf("restored %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+package redaction
+
+import (
+	"ai-service/internal/service/ollama"
+	"context"
+)
+
+// llm redacts everything sent to the wrapped service under a session
+// started with Redactor.Begin and restores the answers. Calls without a
+// session pass through unchanged.
+type llm struct {
+	ollama.LLMService
+}
+
+// Wrap returns next with redaction applied at its boundary.
+func Wrap(next ollama.LLMService) ollama.LLMService {
+	return &llm{LLMService: next}
+}
+
+func (l *llm) Answer(ctx context.Context, orgID string, docEmbeds []float32, messages []ollama.Message, opts ...ollama.RequestOption) (*ollama.ChatResponse, error) {
+	sources, err := l.Search(ctx, orgID, docEmbeds)
+	if err != nil {
+		return nil, err
+	}
+	return l.Generate(ctx, orgID, sources, messages, opts...)
+}
+
+func (l *llm) AnswerStream(ctx context.Context, orgID string, docEmbeds []float32, messages []ollama.Message, fn func(*ollama.ChatResponse) error, opts ...ollama.RequestOption) error {
+	sources, err := l.Search(ctx, orgID, docEmbeds)
+	if err != nil {
+		return err
+	}
+	return l.GenerateStream(ctx, orgID, sources, messages, fn, opts...)
+}
+
+func (l *llm) Generate(ctx context.Context, orgID string, sources []ollama.Source, messages []ollama.Message, opts ...ollama.RequestOption) (*ollama.ChatResponse, error) {
+	s := sessionFrom(ctx)
+	if s == nil {
+		return l.LLMService.Generate(ctx, orgID, sources, messages, opts...)
+	}
+	response, err := l.LLMService.Generate(ctx, orgID, s.sources(sources), s.messages(messages), opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.response(response)
+	response.Sources = sources
+	return response, nil
+}
+
+func (l *llm) GenerateStream(ctx context.Context, orgID string, sources []ollama.Source, messages []ollama.Message, fn func(*ollama.ChatResponse) error, opts ...ollama.RequestOption) error {
+	s := sessionFrom(ctx)
+	if s == nil {
+		return l.LLMService.GenerateStream(ctx, orgID, sources, messages, fn, opts...)
+	}
+	r := &restorer{session: s}
+	return l.LLMService.GenerateStream(ctx, orgID, s.sources(sources), s.messages(messages), func(chunk *ollama.ChatResponse) error {
+		chunk.Message.Content = r.push(chunk.Message.Content, chunk.Done)
+		if chunk.Done {
+			chunk.Sources = sources
+		}
+		return fn(chunk)
+	}, opts...)
+}
+
+func (l *llm) Chat(ctx context.Context, messages []ollama.Message, opts ...ollama.RequestOption) (*ollama.ChatResponse, error) {
+	s := sessionFrom(ctx)
+	if s == nil {
+		return l.LLMService.Chat(ctx, messages, opts...)
+	}
+	response, err := l.LLMService.Chat(ctx, s.messages(messages), opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.response(response)
+	return response, nil
+}
+
+func (l *llm) Embed(ctx context.Context, input []string) ([][][]float32, error) {
+	s := sessionFrom(ctx)
+	if s == nil {
+		return l.LLMService.Embed(ctx, input)
+	}
+	redacted := make([]string, len(input))
+	for i, text := range input {
+		redacted[i] = s.Redact(text)
+	}
+	return l.LLMService.Embed(ctx, redacted)
+}
+
+func (s *Session) messages(messages []ollama.Message) []ollama.Message {
+	out := make([]ollama.Message, len(messages))
+	for i, m := range messages {
+		m.Content = s.Redact(m.Content)
+		if len(m.ToolCalls) > 0 {
+			calls := make([]ollama.ToolCall, len(m.ToolCalls))
+			for j, call := range m.ToolCalls {
+				call.Function.Arguments = s.arguments(call.Function.Arguments, s.Redact)
+				calls[j] = call
+			}
+			m.ToolCalls = calls
+		}
+		out[i] = m
+	}
+	return out
+}
+
+func (s *Session) sources(sources []ollama.Source) []ollama.Source {
+	out := make([]ollama.Source, len(sources))
+	for i, source := range sources {
+		source.Text = s.Redact(source.Text)
+		out[i] = source
+	}
+	return out
+}
+
+// response restores the answer text and the arguments of tool calls, so
+// tools run with the real values.
+func (s *Session) response(response *ollama.ChatResponse) {
+	response.Message.Content = s.Restore(response.Message.Content)
+	for i := range response.Message.ToolCalls {
+		args := response.Message.ToolCalls[i].Function.Arguments
+		response.Message.ToolCalls[i].Function.Arguments = s.arguments(args, s.Restore)
+	}
+}
+
+func (s *Session) arguments(args map[string]any, fn func(string) string) map[string]any {
+	if args == nil {
+		return nil
+	}
+	out := make(map[string]any, len(args))
+	for k, v := range args {
+		if str, ok := v.(string); ok {
+			v = fn(str)
+		}
+		out[k] = v
+	}
+	return out
+}
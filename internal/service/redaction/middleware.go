@@ -0,0 +1,20 @@
+package redaction
+
+import (
+	"ai-service/internal/util/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware starts a redaction session for the authenticated user on the
+// request context. It must run after AuthMiddleware.
+func Middleware(r *Redactor) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if uid, ok := middleware.UserIDFromContext(c); ok {
+				req := c.Request()
+				c.SetRequest(req.WithContext(r.Begin(req.Context(), uid)))
+			}
+			return next(c)
+		}
+	}
+}
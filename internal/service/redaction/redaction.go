@@ -0,0 +1,230 @@
+package redaction
+
+import (
+	"ai-service/internal/util/config"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxPlaceholder bounds how much streamed text is held back while waiting
+// for a placeholder to be closed.
+const maxPlaceholder = 24
+
+// placeholderSpace is the range of placeholder numbers.
+const placeholderSpace = 100_000_000
+
+var placeholder = regexp.MustCompile(`\[(PHONE|IIN|CARD|EMAIL)_\d+\]`)
+
+// Redactor decides per org whether personal data is replaced before text
+// reaches the model.
+type Redactor struct {
+	config    *config.Config
+	detectors []detector
+	key       []byte
+}
+
+func NewRedactor(cfg *config.Config) (*Redactor, error) {
+	key := cfg.Redaction.Key
+	if key == "" {
+		key = cfg.JWTSecret
+	}
+	r := &Redactor{config: cfg, key: []byte(key)}
+	if len(cfg.Redaction.Detectors) == 0 {
+		r.detectors = detectors
+		return r, nil
+	}
+	for _, kind := range cfg.Redaction.Detectors {
+		found := false
+		for _, d := range detectors {
+			if d.kind == kind {
+				r.detectors = append(r.detectors, d)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("redaction: unknown detector %q", kind)
+		}
+	}
+	// Keep the precedence order regardless of the config order.
+	sort.SliceStable(r.detectors, func(i, j int) bool {
+		return rank(r.detectors[i].kind) < rank(r.detectors[j].kind)
+	})
+	return r, nil
+}
+
+func rank(kind string) int {
+	for i, d := range detectors {
+		if d.kind == kind {
+			return i
+		}
+	}
+	return len(detectors)
+}
+
+type sessionKey struct{}
+
+// Begin starts a redaction session for orgID on ctx. Every LLM call made
+// with the returned context shares one placeholder mapping, so answers can
+// be restored.
+func (r *Redactor) Begin(ctx context.Context, orgID string) context.Context {
+	if r == nil || !r.config.RedactionEnabled(orgID) {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, &Session{
+		detectors: r.detectors,
+		key:       r.key,
+		orgID:     orgID,
+		forward:   make(map[string]string),
+		reverse:   make(map[string]string),
+	})
+}
+
+func sessionFrom(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey{}).(*Session)
+	return s
+}
+
+// Session maps personal data to placeholders such as [PHONE_1] and back.
+type Session struct {
+	detectors []detector
+	key       []byte
+	orgID     string
+
+	mu      sync.Mutex
+	forward map[string]string
+	reverse map[string]string
+}
+
+type match struct {
+	start, end int
+	kind       string
+}
+
+// Redact replaces personal data in text with placeholders. The same value
+// always gets the same placeholder.
+func (s *Session) Redact(text string) string {
+	if s == nil || text == "" {
+		return text
+	}
+	var matches []match
+	for _, d := range s.detectors {
+		for _, loc := range d.re.FindAllStringIndex(text, -1) {
+			if d.valid != nil && !d.valid(text[loc[0]:loc[1]]) {
+				continue
+			}
+			if overlaps(matches, loc[0], loc[1]) {
+				continue
+			}
+			matches = append(matches, match{start: loc[0], end: loc[1], kind: d.kind})
+		}
+	}
+	if len(matches) == 0 {
+		return text
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out strings.Builder
+	last := 0
+	for _, m := range matches {
+		out.WriteString(text[last:m.start])
+		out.WriteString(s.placeholder(m.kind, text[m.start:m.end]))
+		last = m.end
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+// placeholder numbers a value by a hash of the org and the value keyed
+// with the redaction key, so a value gets the same placeholder in every
+// session of an org: chunks embedded at ingestion match the questions asked
+// about them. Values are compared in their canonical form, so a number
+// written with and without spaces shares a placeholder, which restores to
+// the spelling seen first. A number another value of the session already
+// took moves on to the next one.
+func (s *Session) placeholder(kind, value string) string {
+	id := kind + "\x00" + canonical(kind, value)
+	if p, ok := s.forward[id]; ok {
+		return p
+	}
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(s.orgID + "\x00" + id))
+	n := binary.BigEndian.Uint64(mac.Sum(nil)) % placeholderSpace
+	p := fmt.Sprintf("[%s_%d]", strings.ToUpper(kind), n)
+	for _, taken := s.reverse[p]; taken; _, taken = s.reverse[p] {
+		n = (n + 1) % placeholderSpace
+		p = fmt.Sprintf("[%s_%d]", strings.ToUpper(kind), n)
+	}
+	s.forward[id] = p
+	s.reverse[p] = value
+	return p
+}
+
+// canonical drops the formatting of a value: separators of numbers, a
+// leading 8 of a Kazakhstan phone number and the case of an email.
+func canonical(kind, value string) string {
+	if kind == KindEmail {
+		return strings.ToLower(value)
+	}
+	var b strings.Builder
+	for _, d := range digits(value) {
+		b.WriteByte(byte('0' + d))
+	}
+	number := b.String()
+	if kind == KindPhone && len(number) == 11 && number[0] == '8' {
+		number = "7" + number[1:]
+	}
+	return number
+}
+
+func overlaps(matches []match, start, end int) bool {
+	for _, m := range matches {
+		if start < m.end && m.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// Restore puts the original values back in place of known placeholders.
+func (s *Session) Restore(text string) string {
+	if s == nil || text == "" {
+		return text
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return placeholder.ReplaceAllStringFunc(text, func(p string) string {
+		if value, ok := s.reverse[p]; ok {
+			return value
+		}
+		return p
+	})
+}
+
+// restorer restores a streamed answer, holding back a trailing piece that
+// may be the start of a placeholder split across chunks.
+type restorer struct {
+	session *Session
+	pending string
+}
+
+func (r *restorer) push(text string, done bool) string {
+	r.pending += text
+	cut := len(r.pending)
+	if !done {
+		if i := strings.LastIndexByte(r.pending, '['); i >= 0 && !strings.Contains(r.pending[i:], "]") && len(r.pending)-i < maxPlaceholder {
+			cut = i
+		}
+	}
+	out := r.pending[:cut]
+	r.pending = r.pending[cut:]
+	return r.session.Restore(out)
+}
@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"ai-service/internal/repository"
+	"ai-service/internal/repository/postgres"
+	"ai-service/internal/service/document"
+	"ai-service/internal/service/guardrails"
+	"ai-service/internal/service/ollama"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const maxSearchResults = 10
+
+type searchDocuments struct {
+	llm        ollama.LLMService
+	repository *repository.Repository
+	guard      *guardrails.Guard
+}
+
+// NewSearchDocuments returns the tool that runs a semantic search over the
+// user's documents.
+func NewSearchDocuments(llm ollama.LLMService, repo *repository.Repository, guard *guardrails.Guard) Tool {
+	return &searchDocuments{llm: llm, repository: repo, guard: guard}
+}
+
+func (t *searchDocuments) Definition() ollama.Tool {
+	return ollama.Tool{
+		Type: "function",
+		Function: ollama.ToolFunction{
+			Name:        "search_documents",
+			Description: "Semantic search over the user's uploaded documents. Returns the most relevant text chunks.",
+			Parameters: []byte(`{
+				"type": "object",
+				"properties": {
+					"query": {"type": "string", "description": "What to search for"},
+					"limit": {"type": "integer", "description": "Number of chunks to return, 1-10"}
+				},
+				"required": ["query"]
+			}`),
+		},
+	}
+}
+
+func (t *searchDocuments) Call(ctx context.Context, userID string, args map[string]any) (any, error) {
+	query, _ := args["query"].(string)
+	if query == "" {
+		return nil, errors.New("query is required")
+	}
+	limit := intArg(args, "limit", 5)
+	if limit < 1 || limit > maxSearchResults {
+		limit = 5
+	}
+	embeddings, err := t.llm.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) == 0 || len(embeddings[0]) == 0 {
+		return nil, errors.New("empty query embedding")
+	}
+	searchResult, err := t.repository.Vector.GetTopK(ctx, userID, limit, embeddings[0][0])
+	if err != nil {
+		return nil, err
+	}
+	sources, err := ollama.SourcesFromResults(searchResult)
+	if err != nil {
+		return nil, err
+	}
+	return t.guard.Sources(ctx, userID, sources)
+}
+
+type listDocuments struct {
+	documents *postgres.DocumentRepository
+}
+
+// NewListDocuments returns the tool that lists the user's documents.
+func NewListDocuments(documents *postgres.DocumentRepository) Tool {
+	return &listDocuments{documents: documents}
+}
+
+func (t *listDocuments) Definition() ollama.Tool {
+	return ollama.Tool{
+		Type: "function",
+		Function: ollama.ToolFunction{
+			Name:        "list_documents",
+			Description: "Lists the documents the user has uploaded with their IDs and names.",
+			Parameters:  []byte(`{"type": "object", "properties": {}}`),
+		},
+	}
+}
+
+func (t *listDocuments) Call(ctx context.Context, userID string, _ map[string]any) (any, error) {
+	docs, err := t.documents.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	type item struct {
+		DocumentID   string `json:"document_id"`
+		DocumentName string `json:"document_name"`
+	}
+	items := make([]item, 0, len(docs))
+	for _, d := range docs {
+		// Documents still being ingested cannot be searched yet.
+		if d.Status != document.StatusIndexed {
+			continue
+		}
+		items = append(items, item{DocumentID: d.DocumentID, DocumentName: d.DocumentName})
+	}
+	return items, nil
+}
+
+type getDocumentChunk struct {
+	repository *repository.Repository
+	guard      *guardrails.Guard
+}
+
+// NewGetDocumentChunk returns the tool that reads one chunk of a document.
+func NewGetDocumentChunk(repo *repository.Repository, guard *guardrails.Guard) Tool {
+	return &getDocumentChunk{repository: repo, guard: guard}
+}
+
+func (t *getDocumentChunk) Definition() ollama.Tool {
+	return ollama.Tool{
+		Type: "function",
+		Function: ollama.ToolFunction{
+			Name:        "get_document_chunk",
+			Description: "Returns the text of one chunk of a document by document ID and zero-based chunk index.",
+			Parameters: []byte(`{
+				"type": "object",
+				"properties": {
+					"document_id": {"type": "string"},
+					"index": {"type": "integer", "description": "Zero-based chunk index"}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+	}
+}
+
+func (t *getDocumentChunk) Call(ctx context.Context, userID string, args map[string]any) (any, error) {
+	docID, _ := args["document_id"].(string)
+	if docID == "" {
+		return nil, errors.New("document_id is required")
+	}
+	if err := uuid.Validate(docID); err != nil {
+		return nil, fmt.Errorf("document_id %q is not a document ID", docID)
+	}
+	index := intArg(args, "index", 0)
+	chunks, err := t.repository.Vector.GetChunks(ctx, userID, docID)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(chunks) {
+		return nil, fmt.Errorf("document %s has %d chunks, index %d is out of range", docID, len(chunks), index)
+	}
+	chunk := chunks[index]
+	text, err := t.guard.Text(ctx, userID, docID, chunk.Text)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]any{
+		"document_id": docID,
+		"index":       index,
+		"total":       len(chunks),
+		"text":        text,
+	}
+	if chunk.Page > 0 {
+		result["page"] = chunk.Page
+	}
+	if chunk.Section != "" {
+		result["section"] = chunk.Section
+	}
+	return result, nil
+}
+
+// intArg reads a numeric argument; JSON numbers decode as float64.
+func intArg(args map[string]any, name string, def int) int {
+	switch v := args[name].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return def
+	}
+}
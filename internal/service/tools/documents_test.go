@@ -0,0 +1,21 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGetDocumentChunkRejectsBadID(t *testing.T) {
+	tool := NewGetDocumentChunk(nil, nil)
+	for _, id := range []string{"", "x' or doc_id != '", "../other"} {
+		_, err := tool.Call(context.Background(), "user", map[string]any{"document_id": id})
+		if err == nil {
+			t.Errorf("document_id %q accepted", id)
+		}
+	}
+	_, err := tool.Call(context.Background(), "user", map[string]any{"document_id": "doc' or id != '"})
+	if err == nil || !strings.Contains(err.Error(), "not a document ID") {
+		t.Errorf("got %v, want an invalid document ID error", err)
+	}
+}
@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"ai-service/internal/service/ollama"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const defaultMaxRounds = 5
+
+// Tool is a server-side function the model may invoke during a chat.
+type Tool interface {
+	Definition() ollama.Tool
+	Call(ctx context.Context, userID string, args map[string]any) (any, error)
+}
+
+// Invocation records one tool call made while answering.
+type Invocation struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+	Result    any            `json:"result,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Duration  time.Duration  `json:"duration"`
+}
+
+type Registry struct {
+	tools map[string]Tool
+}
+
+func NewRegistry(tools ...Tool) *Registry {
+	r := &Registry{tools: make(map[string]Tool, len(tools))}
+	for _, t := range tools {
+		r.tools[t.Definition().Function.Name] = t
+	}
+	return r
+}
+
+func (r *Registry) Definitions() []ollama.Tool {
+	defs := make([]ollama.Tool, 0, len(r.tools))
+	for _, t := range r.tools {
+		defs = append(defs, t.Definition())
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Function.Name < defs[j].Function.Name
+	})
+	return defs
+}
+
+// Run lets the model call registered tools for at most maxRounds rounds and
+// returns its final answer with the trace of every invocation. When the
+// rounds are used up the model is asked to answer without tools.
+func (r *Registry) Run(ctx context.Context, llm ollama.LLMService, userID string, messages []ollama.Message, maxRounds int, opts ...ollama.RequestOption) (*ollama.ChatResponse, []Invocation, error) {
+	if maxRounds <= 0 {
+		maxRounds = defaultMaxRounds
+	}
+	defs := r.Definitions()
+	var trace []Invocation
+	for round := 0; round < maxRounds; round++ {
+		response, err := llm.Chat(ctx, messages, append(opts, ollama.WithTools(defs))...)
+		if err != nil {
+			return nil, trace, err
+		}
+		if len(response.Message.ToolCalls) == 0 {
+			return response, trace, nil
+		}
+		messages = append(messages, response.Message)
+		for _, call := range response.Message.ToolCalls {
+			invocation := r.invoke(ctx, userID, call)
+			trace = append(trace, invocation)
+			messages = append(messages, ollama.Message{
+				Role:     "tool",
+				Content:  invocation.content(),
+				ToolName: invocation.Name,
+			})
+		}
+	}
+	response, err := llm.Chat(ctx, messages, opts...)
+	return response, trace, err
+}
+
+func (r *Registry) invoke(ctx context.Context, userID string, call ollama.ToolCall) Invocation {
+	invocation := Invocation{
+		Name:      call.Function.Name,
+		Arguments: call.Function.Arguments,
+	}
+	t, ok := r.tools[call.Function.Name]
+	if !ok {
+		invocation.Error = fmt.Sprintf("unknown tool %q", call.Function.Name)
+		return invocation
+	}
+	start := time.Now()
+	result, err := t.Call(ctx, userID, call.Function.Arguments)
+	invocation.Duration = time.Since(start)
+	if err != nil {
+		invocation.Error = err.Error()
+		return invocation
+	}
+	invocation.Result = result
+	return invocation
+}
+
+// content is what the model sees as the tool's output.
+func (i Invocation) content() string {
+	if i.Error != "" {
+		return "error: " + i.Error
+	}
+	body, err := json.Marshal(i.Result)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return string(body)
+}
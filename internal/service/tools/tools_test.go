@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"ai-service/internal/service/ollama"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// scriptedLLM answers chat calls with the scripted responses in turn and
+// records what it was sent.
+type scriptedLLM struct {
+	ollama.LLMService
+	script []ollama.Message
+	calls  []ollama.ChatRequest
+}
+
+func (s *scriptedLLM) Chat(_ context.Context, messages []ollama.Message, opts ...ollama.RequestOption) (*ollama.ChatResponse, error) {
+	req := ollama.ChatRequest{Messages: append([]ollama.Message(nil), messages...)}
+	for _, opt := range opts {
+		opt(&req)
+	}
+	s.calls = append(s.calls, req)
+	if len(s.script) == 0 {
+		return nil, errors.New("script exhausted")
+	}
+	reply := s.script[0]
+	s.script = s.script[1:]
+	return &ollama.ChatResponse{Message: reply, Done: true}, nil
+}
+
+func toolCall(name string, args map[string]any) ollama.Message {
+	var call ollama.ToolCall
+	call.Function.Name = name
+	call.Function.Arguments = args
+	return ollama.Message{Role: "assistant", ToolCalls: []ollama.ToolCall{call}}
+}
+
+type echoTool struct {
+	userID string
+}
+
+func (t *echoTool) Definition() ollama.Tool {
+	return ollama.Tool{Type: "function", Function: ollama.ToolFunction{Name: "echo"}}
+}
+
+func (t *echoTool) Call(_ context.Context, userID string, args map[string]any) (any, error) {
+	t.userID = userID
+	if args["fail"] == true {
+		return nil, errors.New("echo failed")
+	}
+	return map[string]any{"said": args["text"]}, nil
+}
+
+func TestRunCallsTools(t *testing.T) {
+	tool := &echoTool{}
+	registry := NewRegistry(tool)
+	llm := &scriptedLLM{script: []ollama.Message{
+		toolCall("echo", map[string]any{"text": "привет"}),
+		toolCall("missing", nil),
+		toolCall("echo", map[string]any{"fail": true}),
+		{Role: "assistant", Content: "Готово."},
+	}}
+	response, trace, err := registry.Run(context.Background(), llm, "user", []ollama.Message{{Role: "user", Content: "Скажи привет"}}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Message.Content != "Готово." {
+		t.Errorf("answer %q", response.Message.Content)
+	}
+	if tool.userID != "user" {
+		t.Errorf("tool ran for %q, want the caller", tool.userID)
+	}
+	if len(trace) != 3 {
+		t.Fatalf("trace %+v, want 3 invocations", trace)
+	}
+	if trace[0].Error != "" || trace[1].Error != `unknown tool "missing"` || trace[2].Error != "echo failed" {
+		t.Errorf("trace errors %q, %q, %q", trace[0].Error, trace[1].Error, trace[2].Error)
+	}
+
+	// Each round sees the tool call and its result.
+	second := llm.calls[1].Messages
+	if n := len(second); n != 3 || second[1].ToolCalls == nil || second[2].Role != "tool" || second[2].ToolName != "echo" || second[2].Content != `{"said":"привет"}` {
+		t.Errorf("second round messages %+v", second)
+	}
+	if last := llm.calls[3].Messages; last[len(last)-1].Content != "error: echo failed" {
+		t.Errorf("failed tool reported as %q", last[len(last)-1].Content)
+	}
+	for i, call := range llm.calls {
+		if len(call.Tools) != 1 || call.Tools[0].Function.Name != "echo" {
+			t.Errorf("round %d offered tools %+v", i, call.Tools)
+		}
+	}
+}
+
+func TestRunStopsAfterMaxRounds(t *testing.T) {
+	llm := &scriptedLLM{script: []ollama.Message{
+		toolCall("echo", map[string]any{"text": "1"}),
+		toolCall("echo", map[string]any{"text": "2"}),
+		{Role: "assistant", Content: "Ответ без инструментов."},
+	}}
+	response, trace, err := NewRegistry(&echoTool{}).Run(context.Background(), llm, "user", []ollama.Message{{Role: "user", Content: "?"}}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trace) != 2 || response.Message.Content != "Ответ без инструментов." {
+		t.Fatalf("trace %d, answer %q", len(trace), response.Message.Content)
+	}
+	if final := llm.calls[len(llm.calls)-1]; final.Tools != nil {
+		t.Error("the final call still offers tools")
+	}
+}
+
+func TestRunReturnsLLMError(t *testing.T) {
+	llm := &scriptedLLM{script: []ollama.Message{toolCall("echo", map[string]any{"text": "1"})}}
+	_, trace, err := NewRegistry(&echoTool{}).Run(context.Background(), llm, "user", []ollama.Message{{Role: "user", Content: "?"}}, 3)
+	if err == nil || !strings.Contains(err.Error(), "script exhausted") {
+		t.Fatalf("got %v, want the LLM error", err)
+	}
+	if len(trace) != 1 {
+		t.Errorf("trace %+v, want the call made before the error", trace)
+	}
+}
+
+func TestDefinitionsSorted(t *testing.T) {
+	defs := NewRegistry(NewGetDocumentChunk(nil, nil), NewListDocuments(nil), NewSearchDocuments(nil, nil, nil)).Definitions()
+	var names []string
+	for _, d := range defs {
+		names = append(names, d.Function.Name)
+	}
+	if got := strings.Join(names, ","); got != "get_document_chunk,list_documents,search_documents" {
+		t.Errorf("definitions %s", got)
+	}
+}
+
+func TestIntArg(t *testing.T) {
+	args := map[string]any{"f": 3.0, "i": 4, "s": "5"}
+	for name, want := range map[string]int{"f": 3, "i": 4, "s": 9, "none": 9} {
+		if got := intArg(args, name, 9); got != want {
+			t.Errorf("intArg(%s) = %d, want %d", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package chunker
+
+import (
+	"ai-service/internal/service/document"
+	"ai-service/internal/util/config"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxBytes is the size of the text field in the vector store. No chunk is
+// longer, whatever the configured size.
+const MaxBytes = 10000
+
+const partSeparator = "\n\n"
+
+var (
+	heading      = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	sentenceEnd  = regexp.MustCompile(`[.!?…]+["»)\]]*\s+`)
+	tokenPattern = regexp.MustCompile(`[\p{L}\p{N}]+|[^\s\p{L}\p{N}]`)
+)
+
+// Part is a unit of extracted text, such as a page. Parts are joined with a
+// blank line before splitting.
+type Part struct {
+	Text string
+	Page int
+}
+
+type span struct {
+	start, end int
+}
+
+type splitter struct {
+	runes   []rune
+	size    int
+	overlap int
+	length  func([]rune) int
+}
+
+// Split cuts the parts into chunks of at most cfg.Size units that overlap
+// by about cfg.Overlap units. Markdown headings start a new section and
+// chunks never cross sections; inside a section text is split at
+// paragraphs first, then lines, sentences, words and finally characters.
+func Split(parts []Part, cfg config.Chunking) []document.Chunk {
+	var (
+		full  strings.Builder
+		pages []span
+	)
+	offset := 0
+	for i, part := range parts {
+		if i > 0 {
+			full.WriteString(partSeparator)
+			offset += utf8.RuneCountInString(partSeparator)
+		}
+		n := utf8.RuneCountInString(part.Text)
+		full.WriteString(part.Text)
+		pages = append(pages, span{start: offset, end: offset + n})
+		offset += n
+	}
+
+	s := &splitter{
+		runes:   []rune(full.String()),
+		size:    cfg.Size,
+		overlap: cfg.Overlap,
+		length:  runeLength,
+	}
+	if cfg.Unit == "token" {
+		s.length = tokenLength
+	}
+
+	var chunks []document.Chunk
+	for _, section := range s.sections() {
+		for _, sp := range s.merge(s.split(section.span, 0)) {
+			for _, sp := range s.capBytes(s.trim(sp)) {
+				text := string(s.runes[sp.start:sp.end])
+				// A bare heading adds nothing; its path is on the chunks below.
+				if text == "" || (!strings.Contains(text, "\n") && heading.MatchString(text)) {
+					continue
+				}
+				chunk := document.Chunk{
+					Text:    text,
+					Index:   len(chunks),
+					Start:   sp.start,
+					End:     sp.end,
+					Section: section.path,
+				}
+				for i, page := range pages {
+					if sp.start < page.end || i == len(pages)-1 {
+						chunk.Page = parts[i].Page
+						break
+					}
+				}
+				chunks = append(chunks, chunk)
+			}
+		}
+	}
+	return chunks
+}
+
+type section struct {
+	span
+	path string
+}
+
+// sections splits the text at Markdown heading lines and gives each
+// section the path of headings above it.
+func (s *splitter) sections() []section {
+	var (
+		out   []section
+		stack []string
+		path  string
+		start int
+	)
+	lineStart := 0
+	for i := 0; i <= len(s.runes); i++ {
+		if i < len(s.runes) && s.runes[i] != '\n' {
+			continue
+		}
+		m := heading.FindStringSubmatch(string(s.runes[lineStart:i]))
+		if m != nil {
+			if lineStart > start {
+				out = append(out, section{span: span{start, lineStart}, path: path})
+			}
+			level := len(m[1])
+			if len(stack) >= level {
+				stack = stack[:level-1]
+			}
+			for len(stack) < level-1 {
+				stack = append(stack, "")
+			}
+			stack = append(stack, m[2])
+			path = joinPath(stack)
+			start = lineStart
+		}
+		lineStart = i + 1
+	}
+	if start < len(s.runes) {
+		out = append(out, section{span: span{start, len(s.runes)}, path: path})
+	}
+	return out
+}
+
+func joinPath(stack []string) string {
+	parts := make([]string, 0, len(stack))
+	for _, h := range stack {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
+
+// levels are tried in order until every piece fits.
+var levels = []func([]rune) []int{
+	separatorCuts("\n\n"),
+	separatorCuts("\n"),
+	sentenceCuts,
+	separatorCuts(" "),
+}
+
+// split returns pieces of sp that each fit the size, cutting with the
+// given level and recursing into pieces that are still too long.
+func (s *splitter) split(sp span, level int) []span {
+	if s.length(s.runes[sp.start:sp.end]) <= s.size {
+		return []span{sp}
+	}
+	if level >= len(levels) {
+		return s.hardSplit(sp)
+	}
+	cuts := levels[level](s.runes[sp.start:sp.end])
+	if len(cuts) == 0 {
+		return s.split(sp, level+1)
+	}
+	var out []span
+	from := sp.start
+	for _, cut := range append(cuts, sp.end-sp.start) {
+		piece := span{from, sp.start + cut}
+		if piece.start < piece.end {
+			out = append(out, s.split(piece, level+1)...)
+		}
+		from = piece.end
+	}
+	return out
+}
+
+// hardSplit cuts at every size runes. A token is at least one rune, so the
+// pieces fit in either unit.
+func (s *splitter) hardSplit(sp span) []span {
+	var out []span
+	for start := sp.start; start < sp.end; start += s.size {
+		out = append(out, span{start, min(start+s.size, sp.end)})
+	}
+	return out
+}
+
+// merge joins consecutive pieces into chunks of up to size, starting each
+// chunk with the tail of the previous one up to overlap.
+func (s *splitter) merge(pieces []span) []span {
+	var (
+		out     []span
+		current []span
+	)
+	total := func(spans []span) int {
+		return s.length(s.runes[spans[0].start:spans[len(spans)-1].end])
+	}
+	for _, piece := range pieces {
+		if len(current) > 0 && total(append(current, piece)) > s.size {
+			out = append(out, span{current[0].start, current[len(current)-1].end})
+			for len(current) > 0 && (total(current) > s.overlap || total(append(current, piece)) > s.size) {
+				current = current[1:]
+			}
+		}
+		current = append(current, piece)
+	}
+	if len(current) > 0 {
+		out = append(out, span{current[0].start, current[len(current)-1].end})
+	}
+	return out
+}
+
+func (s *splitter) trim(sp span) span {
+	for sp.start < sp.end && unicode.IsSpace(s.runes[sp.start]) {
+		sp.start++
+	}
+	for sp.end > sp.start && unicode.IsSpace(s.runes[sp.end-1]) {
+		sp.end--
+	}
+	return sp
+}
+
+// capBytes splits sp so that no piece exceeds MaxBytes when encoded.
+func (s *splitter) capBytes(sp span) []span {
+	var out []span
+	start, size := sp.start, 0
+	for i := sp.start; i < sp.end; i++ {
+		n := utf8.RuneLen(s.runes[i])
+		if size+n > MaxBytes {
+			out = append(out, span{start, i})
+			start, size = i, 0
+		}
+		size += n
+	}
+	return append(out, span{start, sp.end})
+}
+
+// separatorCuts cuts right after every occurrence of sep.
+func separatorCuts(sep string) func([]rune) []int {
+	pattern := []rune(sep)
+	return func(text []rune) []int {
+		var cuts []int
+		for i := 0; i+len(pattern) <= len(text); i++ {
+			if string(text[i:i+len(pattern)]) == sep {
+				cuts = append(cuts, i+len(pattern))
+				i += len(pattern) - 1
+			}
+		}
+		if n := len(cuts); n > 0 && cuts[n-1] == len(text) {
+			cuts = cuts[:n-1]
+		}
+		return cuts
+	}
+}
+
+// sentenceCuts cuts after sentence-ending punctuation and the spaces that
+// follow it.
+func sentenceCuts(text []rune) []int {
+	str := string(text)
+	var cuts []int
+	for _, loc := range sentenceEnd.FindAllStringIndex(str, -1) {
+		if cut := utf8.RuneCountInString(str[:loc[1]]); cut < len(text) {
+			cuts = append(cuts, cut)
+		}
+	}
+	return cuts
+}
+
+func runeLength(text []rune) int {
+	return len(text)
+}
+
+// tokenLength approximates a tokenizer: every word, number and punctuation
+// mark counts as one token.
+func tokenLength(text []rune) int {
+	return len(tokenPattern.FindAllStringIndex(string(text), -1))
+}
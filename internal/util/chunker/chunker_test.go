@@ -0,0 +1,180 @@
+package chunker
+
+import (
+	"ai-service/internal/service/document"
+	"ai-service/internal/util/config"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// checkSpans makes sure every chunk is the text between its offsets and
+// chunks are numbered in order.
+func checkSpans(t *testing.T, full string, chunks []document.Chunk) {
+	t.Helper()
+	runes := []rune(full)
+	for i, c := range chunks {
+		if c.Index != i {
+			t.Errorf("chunk %d has index %d", i, c.Index)
+		}
+		if got := string(runes[c.Start:c.End]); got != c.Text {
+			t.Errorf("chunk %d: text %q, offsets give %q", i, c.Text, got)
+		}
+	}
+}
+
+func sentences(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "Предложение номер %d про документ. ", i)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func TestSplitSizeAndOverlap(t *testing.T) {
+	text := sentences(40)
+	cfg := config.Chunking{Size: 120, Overlap: 40, Unit: "char"}
+	chunks := Split([]Part{{Text: text}}, cfg)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want several", len(chunks))
+	}
+	checkSpans(t, text, chunks)
+	for i, c := range chunks {
+		if n := utf8.RuneCountInString(c.Text); n > cfg.Size {
+			t.Errorf("chunk %d has %d runes, size is %d", i, n, cfg.Size)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := chunks[i-1]
+		if c.Start >= prev.End {
+			t.Errorf("chunk %d starts at %d, after the end %d of the previous one", i, c.Start, prev.End)
+		}
+		if overlap := prev.End - c.Start; overlap > cfg.Overlap {
+			t.Errorf("chunks %d and %d overlap by %d, want at most %d", i-1, i, overlap, cfg.Overlap)
+		}
+	}
+	if first, last := chunks[0], chunks[len(chunks)-1]; first.Start != 0 || last.End != utf8.RuneCountInString(text) {
+		t.Errorf("chunks cover [%d, %d), want the whole text", first.Start, last.End)
+	}
+}
+
+func TestSplitWithoutOverlap(t *testing.T) {
+	text := sentences(20)
+	chunks := Split([]Part{{Text: text}}, config.Chunking{Size: 100, Unit: "char"})
+	checkSpans(t, text, chunks)
+	for i := 1; i < len(chunks); i++ {
+		if chunks[i].Start < chunks[i-1].End {
+			t.Errorf("chunks %d and %d overlap", i-1, i)
+		}
+	}
+}
+
+func TestSplitPrefersParagraphs(t *testing.T) {
+	first := strings.Repeat("a ", 30)
+	second := strings.Repeat("b ", 30)
+	text := first + "\n\n" + second
+	chunks := Split([]Part{{Text: text}}, config.Chunking{Size: 80, Unit: "char"})
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks, want 2: %q", len(chunks), chunks)
+	}
+	if chunks[0].Text != strings.TrimSpace(first) || chunks[1].Text != strings.TrimSpace(second) {
+		t.Errorf("chunks %q and %q do not follow the paragraphs", chunks[0].Text, chunks[1].Text)
+	}
+}
+
+func TestSplitTokens(t *testing.T) {
+	text := strings.Repeat("слово, ", 200)
+	cfg := config.Chunking{Size: 60, Overlap: 10, Unit: "token"}
+	chunks := Split([]Part{{Text: text}}, cfg)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want several", len(chunks))
+	}
+	for i, c := range chunks {
+		if n := tokenLength([]rune(c.Text)); n > cfg.Size {
+			t.Errorf("chunk %d has %d tokens, size is %d", i, n, cfg.Size)
+		}
+	}
+}
+
+func TestSplitSections(t *testing.T) {
+	text := "Вступление.\n\n# Глава 1\nТекст главы.\n\n## Раздел 1.1\nТекст раздела.\n\n# Глава 2\nКонец."
+	chunks := Split([]Part{{Text: text}}, config.Chunking{Size: 1000, Unit: "char"})
+	checkSpans(t, text, chunks)
+	want := []struct{ section, text string }{
+		{"", "Вступление."},
+		{"Глава 1", "Текст главы."},
+		{"Глава 1 > Раздел 1.1", "Текст раздела."},
+		{"Глава 2", "Конец."},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks, want %d: %q", len(chunks), len(want), chunks)
+	}
+	for i, w := range want {
+		if chunks[i].Section != w.section {
+			t.Errorf("chunk %d: section %q, want %q", i, chunks[i].Section, w.section)
+		}
+		if !strings.Contains(chunks[i].Text, w.text) {
+			t.Errorf("chunk %d: %q does not contain %q", i, chunks[i].Text, w.text)
+		}
+	}
+}
+
+func TestSplitSkipsBareHeadings(t *testing.T) {
+	chunks := Split([]Part{{Text: "# Пусто\n\n# Глава\nТекст."}}, config.Chunking{Size: 1000, Unit: "char"})
+	if len(chunks) != 1 || chunks[0].Section != "Глава" {
+		t.Fatalf("got %q, want one chunk of section Глава", chunks)
+	}
+}
+
+func TestSplitPages(t *testing.T) {
+	parts := []Part{
+		{Text: "Первая страница, текст документа.", Page: 1},
+		{Text: "Вторая страница, текст документа.", Page: 2},
+	}
+	chunks := Split(parts, config.Chunking{Size: 50, Unit: "char"})
+	checkSpans(t, parts[0].Text+partSeparator+parts[1].Text, chunks)
+	if len(chunks) != 2 || chunks[0].Page != 1 || chunks[1].Page != 2 {
+		t.Fatalf("got %+v, want one chunk per page", chunks)
+	}
+}
+
+func TestSplitCapsBytes(t *testing.T) {
+	// 4000 runes of three bytes each exceed the field of the vector store.
+	text := strings.Repeat("中", 4000)
+	chunks := Split([]Part{{Text: text}}, config.Chunking{Size: 4000, Unit: "char"})
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks, want 2", len(chunks))
+	}
+	checkSpans(t, text, chunks)
+	var joined strings.Builder
+	for i, c := range chunks {
+		if len(c.Text) > MaxBytes {
+			t.Errorf("chunk %d has %d bytes, max is %d", i, len(c.Text), MaxBytes)
+		}
+		joined.WriteString(c.Text)
+	}
+	if joined.String() != text {
+		t.Error("chunks do not add up to the text")
+	}
+}
+
+func TestSplitRows(t *testing.T) {
+	tables := []Table{
+		{Name: "Лист1", Page: 1, Records: []string{"A: 1", "A: 2", "A: 3"}},
+		{Name: "Лист2", Page: 2, Records: []string{"B: 1"}},
+	}
+	chunks := SplitRows(tables, config.Chunking{Size: 1000, Unit: "char", MaxRows: 2})
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3: %q", len(chunks), chunks)
+	}
+	for i, c := range chunks {
+		if c.Index != i {
+			t.Errorf("chunk %d has index %d", i, c.Index)
+		}
+	}
+	if chunks[2].Page != 2 || strings.Contains(chunks[2].Text, "A:") {
+		t.Errorf("chunk %q crosses tables", chunks[2].Text)
+	}
+}
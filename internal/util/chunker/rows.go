@@ -0,0 +1,93 @@
+package chunker
+
+import (
+	"ai-service/internal/service/document"
+	"ai-service/internal/util/config"
+	"strings"
+	"unicode/utf8"
+)
+
+// Table is a sheet of records, each a self-contained block of text such as
+// a row written as "Column: value" lines.
+type Table struct {
+	Name    string
+	Page    int
+	Records []string
+}
+
+// SplitRows groups consecutive records of each table into chunks of at most
+// cfg.Size units and cfg.MaxRows records. Chunks do not overlap and never
+// cross tables; a record is only cut when it alone exceeds the size.
+func SplitRows(tables []Table, cfg config.Chunking) []document.Chunk {
+	var (
+		full    strings.Builder
+		records [][]span
+	)
+	offset := 0
+	for _, table := range tables {
+		var spans []span
+		for _, record := range table.Records {
+			if offset > 0 {
+				full.WriteString(partSeparator)
+				offset += utf8.RuneCountInString(partSeparator)
+			}
+			n := utf8.RuneCountInString(record)
+			full.WriteString(record)
+			spans = append(spans, span{start: offset, end: offset + n})
+			offset += n
+		}
+		records = append(records, spans)
+	}
+
+	s := &splitter{
+		runes:   []rune(full.String()),
+		size:    cfg.Size,
+		overlap: cfg.Overlap,
+		length:  runeLength,
+	}
+	if cfg.Unit == "token" {
+		s.length = tokenLength
+	}
+
+	var chunks []document.Chunk
+	add := func(table Table, sp span) {
+		for _, sp := range s.capBytes(s.trim(sp)) {
+			if sp.start == sp.end {
+				continue
+			}
+			chunks = append(chunks, document.Chunk{
+				Text:    string(s.runes[sp.start:sp.end]),
+				Index:   len(chunks),
+				Start:   sp.start,
+				End:     sp.end,
+				Page:    table.Page,
+				Section: table.Name,
+			})
+		}
+	}
+	for i, table := range tables {
+		var group []span
+		flush := func() {
+			if len(group) > 0 {
+				add(table, span{group[0].start, group[len(group)-1].end})
+				group = nil
+			}
+		}
+		for _, record := range records[i] {
+			if s.length(s.runes[record.start:record.end]) > s.size {
+				flush()
+				for _, sp := range s.merge(s.split(record, 0)) {
+					add(table, sp)
+				}
+				continue
+			}
+			if len(group) > 0 && (len(group) >= cfg.MaxRows ||
+				s.length(s.runes[group[0].start:record.end]) > s.size) {
+				flush()
+			}
+			group = append(group, record)
+		}
+		flush()
+	}
+	return chunks
+}
@@ -0,0 +1,74 @@
+package chunker
+
+import (
+	"ai-service/internal/util/config"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func records(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = fmt.Sprintf("Товар: позиция %d\nЦена: %d", i, 100+i)
+	}
+	return out
+}
+
+func TestSplitRowsGroupsRecords(t *testing.T) {
+	tables := []Table{
+		{Name: "Цены", Page: 1, Records: records(5)},
+		{Name: "Склад", Page: 2, Records: records(1)},
+	}
+	chunks := SplitRows(tables, config.Chunking{Size: 1000, Unit: "char", MaxRows: 2})
+	full := strings.Join(append(records(5), records(1)...), partSeparator)
+	checkSpans(t, full, chunks)
+
+	want := []struct {
+		rows    int
+		section string
+		page    int
+	}{{2, "Цены", 1}, {2, "Цены", 1}, {1, "Цены", 1}, {1, "Склад", 2}}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks, want %d: %q", len(chunks), len(want), chunks)
+	}
+	for i, w := range want {
+		c := chunks[i]
+		if rows := strings.Count(c.Text, "Товар:"); rows != w.rows || c.Section != w.section || c.Page != w.page {
+			t.Errorf("chunk %d: %d rows in %s page %d, want %d rows in %s page %d", i, rows, c.Section, c.Page, w.rows, w.section, w.page)
+		}
+	}
+}
+
+func TestSplitRowsRespectsSize(t *testing.T) {
+	rows := records(6)
+	size := len([]rune(rows[0]))*2 + len(partSeparator)
+	chunks := SplitRows([]Table{{Page: 1, Records: rows}}, config.Chunking{Size: size, Unit: "char", MaxRows: 10})
+	checkSpans(t, strings.Join(rows, partSeparator), chunks)
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3 of two rows each", len(chunks))
+	}
+	for i, c := range chunks {
+		if strings.Count(c.Text, "Товар:") != 2 {
+			t.Errorf("chunk %d cuts a row: %q", i, c.Text)
+		}
+	}
+}
+
+func TestSplitRowsCutsLongRecord(t *testing.T) {
+	long := "Описание: " + sentences(10)
+	rows := []string{"Товар: чай", long, "Товар: кофе"}
+	chunks := SplitRows([]Table{{Page: 1, Records: rows}}, config.Chunking{Size: 100, Unit: "char", MaxRows: 10})
+	checkSpans(t, strings.Join(rows, partSeparator), chunks)
+	if len(chunks) < 4 {
+		t.Fatalf("got %d chunks, want the long record cut apart: %q", len(chunks), chunks)
+	}
+	if chunks[0].Text != "Товар: чай" || chunks[len(chunks)-1].Text != "Товар: кофе" {
+		t.Errorf("the short records were merged into the long one: %q", chunks)
+	}
+	for i, c := range chunks {
+		if n := len([]rune(c.Text)); n > 100 {
+			t.Errorf("chunk %d has %d runes, size is 100", i, n)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package config
+
+import "fmt"
+
+const (
+	defaultChunkSize    = 1000
+	defaultChunkOverlap = 150
+	maxChunkSize        = 4000
+	defaultMaxRows      = 20
+)
+
+// validate fills in the defaults and checks the limits. Chunks have to fit
+// the 10000-byte text field of the vector store.
+func (c *Chunking) validate() error {
+	switch c.Unit {
+	case "":
+		c.Unit = "char"
+	case "char", "token":
+	default:
+		return fmt.Errorf("unit must be char or token")
+	}
+	if c.Size == 0 {
+		c.Size = defaultChunkSize
+		if c.Overlap == 0 {
+			c.Overlap = defaultChunkOverlap
+		}
+	}
+	if c.Size < 50 || c.Size > maxChunkSize {
+		return fmt.Errorf("size must be between 50 and %d", maxChunkSize)
+	}
+	if c.Overlap < 0 || c.Overlap > c.Size/2 {
+		return fmt.Errorf("overlap must be between 0 and half the size")
+	}
+	if c.MaxRows == 0 {
+		c.MaxRows = defaultMaxRows
+	}
+	if c.MaxRows < 1 {
+		return fmt.Errorf("max_rows must be positive")
+	}
+	return nil
+}
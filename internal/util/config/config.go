@@ -8,10 +8,11 @@ import (
 )
 
 type Config struct {
-	Port      string `json:"port"`
-	Name      string `json:"name"`
-	JWTSecret string `json:"jwt_secret"`
-	Ollama    Ollama `json:"ollama"`
+	Port      string   `json:"port"`
+	Name      string   `json:"name"`
+	JWTSecret string   `json:"jwt_secret"`
+	Admins    []string `json:"admins"`
+	Ollama    Ollama   `json:"ollama"`
 	Milvus    struct {
 		Host       string `json:"host"`
 		Port       string `json:"port"`
@@ -23,7 +24,123 @@ type Config struct {
 		OpenConns  int    `json:"open_conns"`
 		DriverName string `json:"driver_name"`
 	} `json:"milvus"`
-	DB DBConfig `json:"db"`
+	DB          DBConfig       `json:"db"`
+	Idempotency Idempotency    `json:"idempotency"`
+	AnswerCache AnswerCache    `json:"answer_cache"`
+	Grounding   Grounding      `json:"grounding"`
+	Guardrails  Guardrails     `json:"guardrails"`
+	Redaction   Redaction      `json:"redaction"`
+	Chunking    Chunking       `json:"chunking"`
+	Extraction  Extraction     `json:"extraction"`
+	OCR         OCR            `json:"ocr"`
+	Upload      Upload         `json:"upload"`
+	Ingestion   Ingestion      `json:"ingestion"`
+	Orgs        map[string]Org `json:"orgs"`
+}
+
+type Idempotency struct {
+	TTL time.Duration `json:"ttl"`
+}
+
+// AnswerCache serves repeated questions from earlier answers. Threshold is
+// the minimum cosine similarity between the question embeddings.
+type AnswerCache struct {
+	Enabled    bool          `json:"enabled"`
+	Threshold  float64       `json:"threshold"`
+	TTL        time.Duration `json:"ttl"`
+	MaxEntries int           `json:"max_entries"`
+}
+
+// Grounding checks answers against the retrieved chunks with a judge model
+// and flags or blocks answers whose supported share is below Threshold.
+type Grounding struct {
+	Enabled      bool    `json:"enabled"`
+	Model        string  `json:"model"`
+	Threshold    float64 `json:"threshold"`
+	Action       string  `json:"action"`
+	BlockMessage string  `json:"block_message"`
+}
+
+// Guardrails screens user messages and retrieved chunks for prompt
+// injection. Without Rules the built-in rules are used; ClassifierModel adds
+// a model-based check on top of them.
+type Guardrails struct {
+	Enabled         bool            `json:"enabled"`
+	ClassifierModel string          `json:"classifier_model"`
+	Rules           []GuardrailRule `json:"rules"`
+}
+
+// GuardrailRule matches Pattern in the content named by Scope (user,
+// document or both). Action block rejects the message or drops the chunk,
+// neutralise cuts the match out.
+type GuardrailRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	Action  string `json:"action"`
+	Scope   string `json:"scope"`
+}
+
+// Redaction replaces personal data with placeholders before text reaches
+// the model. Detectors picks from phone, iin, card and email; empty means all.
+// Placeholders are numbered by a hash keyed with Key, JWTSecret when empty.
+type Redaction struct {
+	Enabled   bool     `json:"enabled"`
+	Detectors []string `json:"detectors"`
+	Key       string   `json:"key"`
+}
+
+// Extraction chooses the optional parts of documents that are indexed
+// along with the body.
+type Extraction struct {
+	HeadersFooters bool `json:"headers_footers"`
+	Footnotes      bool `json:"footnotes"`
+}
+
+// OCR reads the text of scans and pictures. Backend llm sends them to a
+// vision model, Model or else the active chat model; http posts them to
+// URL with Token as bearer. An empty Backend turns OCR off.
+type OCR struct {
+	Backend string        `json:"backend"`
+	Model   string        `json:"model"`
+	URL     string        `json:"url"`
+	Token   string        `json:"token"`
+	Timeout time.Duration `json:"timeout"`
+}
+
+// MaxUploadFileSize caps Upload.MaxFileSize. A queued file is stored whole
+// as the payload of its ingestion job and read into memory to enqueue and
+// to ingest it, so the file size bounds the memory an upload takes.
+const MaxUploadFileSize = 256 << 20
+
+// Upload limits multipart uploads. MaxFileSize is in bytes, at most
+// MaxUploadFileSize; files are spooled to TempDir, the system default when
+// empty.
+type Upload struct {
+	MaxFileSize int64  `json:"max_file_size"`
+	MaxFiles    int    `json:"max_files"`
+	TempDir     string `json:"temp_dir"`
+}
+
+// Ingestion runs uploads in the background on Workers goroutines, which
+// look for queued jobs every PollInterval. A job is leased to its worker for
+// Lease at a time; one failing with a transient error is retried up to
+// MaxAttempts times. Chunks are embedded EmbedBatch at a time.
+type Ingestion struct {
+	Workers      int           `json:"workers"`
+	PollInterval time.Duration `json:"poll_interval"`
+	Lease        time.Duration `json:"lease"`
+	MaxAttempts  int           `json:"max_attempts"`
+	EmbedBatch   int           `json:"embed_batch"`
+}
+
+// Chunking sizes the pieces documents are split into. Size and Overlap are
+// counted in Unit: char or token. Spreadsheet chunks also hold at most
+// MaxRows rows.
+type Chunking struct {
+	Size    int    `json:"size"`
+	Overlap int    `json:"overlap"`
+	Unit    string `json:"unit"`
+	MaxRows int    `json:"max_rows"`
 }
 
 type DBConfig struct {
@@ -45,10 +162,20 @@ func (cfg *DBConfig) DSN() string {
 }
 
 type Ollama struct {
-	Model     string            `json:"model"`
-	Url       string            `json:"url"`
-	Endpoints map[string]string `json:"endpoints"`
-	Timeout   time.Duration     `json:"timeout"`
+	Model          string            `json:"model"`
+	EmbedModel     string            `json:"embed_model"`
+	Url            string            `json:"url"`
+	Nodes          []string          `json:"nodes"`
+	Endpoints      map[string]string `json:"endpoints"`
+	Timeout        time.Duration     `json:"timeout"`
+	HealthInterval time.Duration     `json:"health_interval"`
+	MaxToolRounds  int               `json:"max_tool_rounds"`
+	FormatRetries  int               `json:"format_retries"`
+	MaxImageSize   int               `json:"max_image_size"`
+	MaxImages      int               `json:"max_images"`
+	MaxConcurrent  int               `json:"max_concurrent"`
+	MaxQueue       int               `json:"max_queue"`
+	Options        GenerationOptions `json:"options"`
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -70,5 +197,74 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, err
 	}
 	config.Ollama.Timeout = config.Ollama.Timeout * time.Second
+	config.Ollama.HealthInterval = config.Ollama.HealthInterval * time.Second
+	config.Idempotency.TTL = config.Idempotency.TTL * time.Second
+	if config.Idempotency.TTL == 0 {
+		config.Idempotency.TTL = 24 * time.Hour
+	}
+	config.AnswerCache.TTL = config.AnswerCache.TTL * time.Second
+	if config.AnswerCache.Threshold == 0 {
+		config.AnswerCache.Threshold = 0.95
+	}
+	if config.AnswerCache.Threshold < 0 || config.AnswerCache.Threshold > 1 {
+		return nil, fmt.Errorf("answer_cache.threshold must be between 0 and 1")
+	}
+	if config.Grounding.Threshold == 0 {
+		config.Grounding.Threshold = 0.7
+	}
+	switch config.Grounding.Action {
+	case "":
+		config.Grounding.Action = "flag"
+	case "flag", "block":
+	default:
+		return nil, fmt.Errorf("grounding.action must be flag or block")
+	}
+	config.OCR.Timeout = config.OCR.Timeout * time.Second
+	switch config.OCR.Backend {
+	case "", "llm":
+	case "http":
+		if config.OCR.URL == "" {
+			return nil, fmt.Errorf("ocr.url is required for the http backend")
+		}
+	default:
+		return nil, fmt.Errorf("ocr.backend must be llm or http")
+	}
+	if config.Upload.MaxFileSize == 0 {
+		config.Upload.MaxFileSize = 50 << 20
+	}
+	if config.Upload.MaxFileSize < 0 || config.Upload.MaxFileSize > MaxUploadFileSize {
+		return nil, fmt.Errorf("upload.max_file_size must be between 1 and %d bytes", MaxUploadFileSize)
+	}
+	if config.Upload.MaxFiles == 0 {
+		config.Upload.MaxFiles = 10
+	}
+	config.Ingestion.PollInterval = config.Ingestion.PollInterval * time.Second
+	config.Ingestion.Lease = config.Ingestion.Lease * time.Second
+	if config.Ingestion.Workers == 0 {
+		config.Ingestion.Workers = 2
+	}
+	if config.Ingestion.PollInterval == 0 {
+		config.Ingestion.PollInterval = 2 * time.Second
+	}
+	if config.Ingestion.Lease == 0 {
+		config.Ingestion.Lease = 10 * time.Minute
+	}
+	if config.Ingestion.MaxAttempts == 0 {
+		config.Ingestion.MaxAttempts = 3
+	}
+	if config.Ingestion.EmbedBatch == 0 {
+		config.Ingestion.EmbedBatch = 32
+	}
+	if err := config.Chunking.validate(); err != nil {
+		return nil, fmt.Errorf("chunking: %w", err)
+	}
+	if err := config.Ollama.Options.Validate(); err != nil {
+		return nil, fmt.Errorf("ollama.options: %w", err)
+	}
+	for id := range config.Orgs {
+		if err := config.GenerationOptions(id).Validate(); err != nil {
+			return nil, fmt.Errorf("orgs.%s.options: %w", id, err)
+		}
+	}
 	return config, nil
 }
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func loadJSON(t *testing.T, content string) (*Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "conf.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return LoadConfig(path)
+}
+
+func TestLoadConfigIngestion(t *testing.T) {
+	cfg, err := loadJSON(t, `{}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Ingestion{Workers: 2, PollInterval: 2 * time.Second, Lease: 10 * time.Minute, MaxAttempts: 3, EmbedBatch: 32}
+	if cfg.Ingestion != want {
+		t.Errorf("defaults %+v, want %+v", cfg.Ingestion, want)
+	}
+
+	cfg, err = loadJSON(t, `{"ingestion": {"workers": 4, "poll_interval": 5, "lease": 60, "max_attempts": 1, "embed_batch": 8}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = Ingestion{Workers: 4, PollInterval: 5 * time.Second, Lease: time.Minute, MaxAttempts: 1, EmbedBatch: 8}
+	if cfg.Ingestion != want {
+		t.Errorf("configured %+v, want %+v", cfg.Ingestion, want)
+	}
+}
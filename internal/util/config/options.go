@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// GenerationOptions are the sampling and context parameters passed to
+// Ollama. Unset fields fall back to the next level: request, org, config
+// defaults, model defaults.
+type GenerationOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	NumCtx      *int     `json:"num_ctx,omitempty"`
+	NumPredict  *int     `json:"num_predict,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	KeepAlive   string   `json:"keep_alive,omitempty"`
+}
+
+type Org struct {
+	Options *GenerationOptions `json:"options"`
+	// Redaction overrides redaction.enabled for the org.
+	Redaction *bool `json:"redaction,omitempty"`
+}
+
+const (
+	maxNumCtx     = 131072
+	maxNumPredict = 32768
+	maxStop       = 8
+)
+
+// Merge returns o with every field set in over replacing its own.
+func (o GenerationOptions) Merge(over *GenerationOptions) GenerationOptions {
+	if over == nil {
+		return o
+	}
+	if over.Temperature != nil {
+		o.Temperature = over.Temperature
+	}
+	if over.TopP != nil {
+		o.TopP = over.TopP
+	}
+	if over.NumCtx != nil {
+		o.NumCtx = over.NumCtx
+	}
+	if over.NumPredict != nil {
+		o.NumPredict = over.NumPredict
+	}
+	if over.Seed != nil {
+		o.Seed = over.Seed
+	}
+	if over.Stop != nil {
+		o.Stop = over.Stop
+	}
+	if over.KeepAlive != "" {
+		o.KeepAlive = over.KeepAlive
+	}
+	return o
+}
+
+func (o GenerationOptions) Validate() error {
+	if o.Temperature != nil && (*o.Temperature < 0 || *o.Temperature > 2) {
+		return fmt.Errorf("temperature must be between 0 and 2")
+	}
+	if o.TopP != nil && (*o.TopP <= 0 || *o.TopP > 1) {
+		return fmt.Errorf("top_p must be greater than 0 and at most 1")
+	}
+	if o.NumCtx != nil && (*o.NumCtx < 256 || *o.NumCtx > maxNumCtx) {
+		return fmt.Errorf("num_ctx must be between 256 and %d", maxNumCtx)
+	}
+	// -1 generates until the model stops, -2 until the context is full.
+	if o.NumPredict != nil && (*o.NumPredict < -2 || *o.NumPredict == 0 || *o.NumPredict > maxNumPredict) {
+		return fmt.Errorf("num_predict must be -2, -1 or between 1 and %d", maxNumPredict)
+	}
+	if o.Seed != nil && *o.Seed < 0 {
+		return fmt.Errorf("seed must not be negative")
+	}
+	if len(o.Stop) > maxStop {
+		return fmt.Errorf("at most %d stop sequences are allowed", maxStop)
+	}
+	for _, s := range o.Stop {
+		if s == "" {
+			return fmt.Errorf("stop sequences must not be empty")
+		}
+	}
+	if o.KeepAlive != "" {
+		if _, err := strconv.Atoi(o.KeepAlive); err != nil {
+			if _, err := time.ParseDuration(o.KeepAlive); err != nil {
+				return fmt.Errorf("keep_alive must be a duration such as \"5m\" or a number of seconds")
+			}
+		}
+	}
+	return nil
+}
+
+// GenerationOptions returns the config defaults with the org's overrides
+// applied.
+func (c *Config) GenerationOptions(orgID string) GenerationOptions {
+	return c.Ollama.Options.Merge(c.Orgs[orgID].Options)
+}
+
+// RedactionEnabled reports whether personal data is redacted for orgID.
+func (c *Config) RedactionEnabled(orgID string) bool {
+	if enabled := c.Orgs[orgID].Redaction; enabled != nil {
+		return *enabled
+	}
+	return c.Redaction.Enabled
+}
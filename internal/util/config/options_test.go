@@ -0,0 +1,69 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestMerge(t *testing.T) {
+	base := GenerationOptions{Temperature: ptr(0.8), NumCtx: ptr(4096), Stop: []string{"###"}, KeepAlive: "5m"}
+	if got := base.Merge(nil); !reflect.DeepEqual(got, base) {
+		t.Errorf("Merge(nil) = %+v, want the base", got)
+	}
+	got := base.Merge(&GenerationOptions{Temperature: ptr(0.0), Seed: ptr(42), Stop: []string{}})
+	want := GenerationOptions{Temperature: ptr(0.0), NumCtx: ptr(4096), Seed: ptr(42), Stop: []string{}, KeepAlive: "5m"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge = %+v, want %+v", got, want)
+	}
+	if *base.Temperature != 0.8 {
+		t.Error("Merge changed the base")
+	}
+}
+
+func TestGenerationOptionsPerOrg(t *testing.T) {
+	cfg := &Config{Orgs: map[string]Org{"acme": {Options: &GenerationOptions{TopP: ptr(0.5)}}}}
+	cfg.Ollama.Options = GenerationOptions{Temperature: ptr(0.3)}
+	if got := cfg.GenerationOptions("acme"); *got.Temperature != 0.3 || got.TopP == nil || *got.TopP != 0.5 {
+		t.Errorf("options of acme %+v", got)
+	}
+	if got := cfg.GenerationOptions("other"); got.TopP != nil || *got.Temperature != 0.3 {
+		t.Errorf("options of another org %+v", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts GenerationOptions
+		err  string
+	}{
+		{"empty", GenerationOptions{}, ""},
+		{"all set", GenerationOptions{Temperature: ptr(0.0), TopP: ptr(1.0), NumCtx: ptr(8192), NumPredict: ptr(-1), Seed: ptr(0), Stop: []string{"\n\n"}, KeepAlive: "10m"}, ""},
+		{"keep_alive seconds", GenerationOptions{KeepAlive: "-1"}, ""},
+		{"temperature", GenerationOptions{Temperature: ptr(2.5)}, "temperature"},
+		{"negative temperature", GenerationOptions{Temperature: ptr(-0.1)}, "temperature"},
+		{"top_p zero", GenerationOptions{TopP: ptr(0.0)}, "top_p"},
+		{"num_ctx small", GenerationOptions{NumCtx: ptr(128)}, "num_ctx"},
+		{"num_ctx large", GenerationOptions{NumCtx: ptr(maxNumCtx + 1)}, "num_ctx"},
+		{"num_predict zero", GenerationOptions{NumPredict: ptr(0)}, "num_predict"},
+		{"num_predict -3", GenerationOptions{NumPredict: ptr(-3)}, "num_predict"},
+		{"negative seed", GenerationOptions{Seed: ptr(-1)}, "seed"},
+		{"too many stops", GenerationOptions{Stop: make([]string, maxStop+1)}, "stop"},
+		{"empty stop", GenerationOptions{Stop: []string{""}}, "stop"},
+		{"keep_alive", GenerationOptions{KeepAlive: "forever"}, "keep_alive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			switch {
+			case tt.err == "" && err != nil:
+				t.Fatalf("Validate: %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Fatalf("Validate: %v, want an error about %s", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package doc
+
+import (
+	"ai-service/internal/util/chunker"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvDelimiters are tried in order; Excel writes ";" in Russian locales.
+var csvDelimiters = []rune{';', '\t', ','}
+
+// csvSample is how many records detection reads.
+const csvSample = 50
+
+func readCSV(data []byte) ([]chunker.Table, error) {
+	text := decodeText(data)
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = csvDelimiter(text)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return []chunker.Table{{Page: 1, Records: rowRecords(rows)}}, nil
+}
+
+// looksLikeCSV tells CSV from plain text, which sniff the same.
+func looksLikeCSV(data []byte) bool {
+	return csvDelimiter(string(data)) != 0
+}
+
+// csvDelimiter returns the first delimiter that splits the first records
+// of text into the same number of fields, at least two, or 0.
+func csvDelimiter(text string) rune {
+	for _, comma := range csvDelimiters {
+		r := csv.NewReader(strings.NewReader(text))
+		r.Comma = comma
+		r.FieldsPerRecord = 0
+		r.LazyQuotes = true
+		n := 0
+		for ; n < csvSample; n++ {
+			record, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil || len(record) < 2 {
+				n = 0
+				break
+			}
+		}
+		if n >= 3 {
+			return comma
+		}
+	}
+	return 0
+}
+
+// rowRecords turns the rows after the header into "Column: value" lines,
+// one record per row, so that every chunk names what its values are.
+// Empty cells are left out and columns without a header are numbered.
+func rowRecords(rows [][]string) []string {
+	var header []string
+	var records []string
+	for _, row := range rows {
+		for i := range row {
+			row[i] = strings.TrimSpace(row[i])
+		}
+		if isEmptyRow(row) {
+			continue
+		}
+		if header == nil && len(rows) > 1 {
+			header = row
+			continue
+		}
+		var b strings.Builder
+		for i, value := range row {
+			if value == "" {
+				continue
+			}
+			name := "Столбец " + strconv.Itoa(i+1)
+			if i < len(header) && header[i] != "" {
+				name = header[i]
+			}
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(name + ": " + value)
+		}
+		records = append(records, b.String())
+	}
+	return records
+}
+
+func isEmptyRow(row []string) bool {
+	for _, value := range row {
+		if value != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package doc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCSVDelimiter(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want rune
+	}{
+		{"semicolon", "Товар;Цена\nЧай;100,5\nКофе;200\n", ';'},
+		{"tab", "a\tb\n1\t2\n3\t4\n", '\t'},
+		{"comma", "a,b,c\n1,2,3\n4,5,6\n", ','},
+		{"quoted comma", "\"Иванов, И.\",1\n\"Петров, П.\",2\n\"Сидоров, С.\",3\n", ','},
+		{"too few records", "a,b\n1,2\n", 0},
+		{"ragged", "a,b\n1,2,3\n4\n", 0},
+		{"prose", "Просто текст.\nЕщё строка, с запятой.\nИ ещё одна.\n", 0},
+	}
+	for _, tt := range tests {
+		if got := csvDelimiter(tt.text); got != tt.want {
+			t.Errorf("%s: csvDelimiter() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	data := []byte("Товар; Цена ;\n\nЧай;100;в пачке\n;;\nКофе;;\n")
+	tables, err := readCSV(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Товар: Чай\nЦена: 100\nСтолбец 3: в пачке",
+		"Товар: Кофе",
+	}
+	if len(tables) != 1 || tables[0].Page != 1 || !reflect.DeepEqual(tables[0].Records, want) {
+		t.Errorf("readCSV() = %+v, want records %q", tables, want)
+	}
+}
+
+func TestRowRecordsSingleRow(t *testing.T) {
+	got := rowRecords([][]string{{"только", "значения"}})
+	want := []string{"Столбец 1: только\nСтолбец 2: значения"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rowRecords() = %q, want %q", got, want)
+	}
+}
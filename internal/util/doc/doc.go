@@ -1,170 +1,92 @@
 package doc
 
 import (
-	"archive/zip"
-	"bytes"
+	"ai-service/internal/service/document"
+	"ai-service/internal/util/chunker"
+	"ai-service/internal/util/config"
+	"context"
 	"encoding/base64"
-	"encoding/xml"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"strconv"
 	"strings"
-
-	"github.com/dslipak/pdf"
 )
 
-type WordXML struct {
-	XMLName xml.Name `xml:"document"`
-	Body    struct {
-		Paragraphs []struct {
-			Texts []struct {
-				Text string `xml:",chardata"`
-			} `xml:"r>t"`
-		} `xml:"body>p"`
-	} `xml:"body"`
-}
+// ErrNoText is returned for documents that contain no extractable text.
+var ErrNoText = errors.New("в документе не найден текст")
 
-func DecodeBase64ToString(encoded string) (string, error) {
-	decodedBytes, err := base64.StdEncoding.DecodeString(encoded)
-	if err != nil {
-		return "", err
-	}
-	return string(decodedBytes), nil
+// Options tune how documents are extracted and chunked. Without OCR,
+// scans and pictures are reported as image-only.
+type Options struct {
+	Chunking   config.Chunking
+	Extraction config.Extraction
+	OCR        OCR
 }
 
-func DecodeBase64ToFileAndRead(encoded string) ([]string, error) {
-	decodedBytes, err := base64.StdEncoding.DecodeString(encoded)
-	if err != nil {
-		return nil, err
-	}
-
-	// Определение MIME-типа
-	mimeType := http.DetectContentType(decodedBytes)
+// Result is an extracted document: its chunks and how extraction went.
+type Result struct {
+	Chunks []document.Chunk
+	Report document.Report
+}
 
-	// Определение расширения файла
-	extension := strings.TrimPrefix(mimeType, "application/")
-	if extension == "" {
-		extension = "bin"
-	}
-	switch extension {
-	case "zip":
-		extension = "docx"
-	}
-	filename := "temp." + extension
+// loader extracts and chunks a document held in memory.
+type loader func(ctx context.Context, data []byte, opts Options) (*Result, error)
 
-	// Записываем в файл
-	err = os.WriteFile(filename, decodedBytes, 0644)
-	if err != nil {
-		return nil, err
+// prose makes a loader of a reader of running text.
+func prose(read func(data []byte) ([]chunker.Part, error)) loader {
+	return func(_ context.Context, data []byte, opts Options) (*Result, error) {
+		parts, err := read(data)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{Chunks: chunker.Split(parts, opts.Chunking)}, nil
 	}
-	defer os.Remove(filename)
+}
 
-	// Читаем содержимое файла в зависимости от его типа
-	switch mimeType {
-	case "application/pdf":
-		return readPDFFile(filename)
-	case "application/zip":
-		return readWordFile(filename)
-	case "text/plain":
-		return readTextFile(filename)
-	default:
-		return nil, errors.New("неподдерживаемый формат файла")
+// spreadsheet makes a loader of a reader of sheets of records.
+func spreadsheet(read func(data []byte) ([]chunker.Table, error)) loader {
+	return func(_ context.Context, data []byte, opts Options) (*Result, error) {
+		sheets, err := read(data)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{Chunks: chunker.SplitRows(sheets, opts.Chunking)}, nil
 	}
 }
 
-func readTextFile(filename string) ([]string, error) {
-	_, err := os.ReadFile(filename)
+func DecodeBase64ToString(encoded string) (string, error) {
+	decodedBytes, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	return nil, nil
+	return string(decodedBytes), nil
 }
 
-func readPDFFile(filename string) ([]string, error) {
-	r, err := pdf.Open(filename)
+// Read extracts the text of a document and splits it into chunks. The
+// format is detected from the content and, where that is ambiguous, the
+// extension of filename, which may be empty.
+func Read(ctx context.Context, data []byte, filename string, opts Options) (*Result, error) {
+	format, err := Detect(data, filename)
 	if err != nil {
 		return nil, err
 	}
-	var chunks []string
-	for i := 0; i < r.NumPage(); i++ {
-		page := r.Page(i + 1)
-		if page.V.IsNull() {
-			continue
-		}
-		s, err := page.GetPlainText(nil)
-		if err != nil {
-			return nil, err
-		}
-		chunks = append(chunks, s)
-	}
-
-	return chunks, nil
-}
-
-func readWordFile(filename string) ([]string, error) {
-	r, err := zip.OpenReader(filename)
+	result, err := format.load(ctx, data, opts)
 	if err != nil {
 		return nil, err
 	}
-	defer r.Close()
-
-	var textContent string
-
-	for _, file := range r.File {
-		if file.Name == "word/document.xml" {
-			rc, err := file.Open()
-			if err != nil {
-				return nil, err
-			}
-			defer rc.Close()
-
-			// Read XML content
-			buf := new(bytes.Buffer)
-			_, err = io.Copy(buf, rc)
-			if err != nil {
-				return nil, err
-			}
-
-			textContent = extractTextFromXML(buf.String())
-			break
+	if len(result.Chunks) == 0 {
+		if pages := result.Report.ImageOnlyPages; len(pages) > 0 {
+			return nil, fmt.Errorf("%w: страницы %s содержат только изображения", ErrNoText, joinInts(pages))
 		}
+		return nil, ErrNoText
 	}
-
-	if textContent == "" {
-		return nil, fmt.Errorf("could not find 'word/document.xml' in DOCX file")
-	}
-
-	pages := strings.Split(textContent, "[PAGE_BREAK]")
-
-	return pages, nil
+	return result, nil
 }
 
-func extractTextFromXML(xmlContent string) string {
-	decoder := xml.NewDecoder(strings.NewReader(xmlContent))
-	var extractedText strings.Builder
-
-	for {
-		token, err := decoder.Token()
-		if err != nil {
-			break
-		}
-
-		switch t := token.(type) {
-		case xml.StartElement:
-			// Detect page breaks
-			if t.Name.Local == "br" {
-				for _, attr := range t.Attr {
-					if attr.Name.Local == "type" && attr.Value == "page" {
-						extractedText.WriteString("[PAGE_BREAK]") // Mark page break
-					}
-				}
-			}
-		case xml.CharData:
-			extractedText.WriteString(string(t))
-		}
+func joinInts(values []int) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = strconv.Itoa(v)
 	}
-
-	return extractedText.String()
+	return strings.Join(s, ", ")
 }
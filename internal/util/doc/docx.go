@@ -0,0 +1,534 @@
+package doc
+
+import (
+	"ai-service/internal/util/chunker"
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const docxType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+const (
+	wordNS        = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	compatNS      = "http://schemas.openxmlformats.org/markup-compatibility/2006"
+	headerRel     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/header"
+	footerRel     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/footer"
+	docxPageBreak = "\f"
+)
+
+var headingStyle = regexp.MustCompile(`^(?:heading|заголовок)\s*([1-9])$`)
+
+type docxVal struct {
+	Val string `xml:"val,attr"`
+}
+
+type docxNumPr struct {
+	ILvl  *docxVal `xml:"ilvl"`
+	NumID *docxVal `xml:"numId"`
+}
+
+type docxStyles struct {
+	Styles []struct {
+		ID      string  `xml:"styleId,attr"`
+		Name    docxVal `xml:"name"`
+		BasedOn docxVal `xml:"basedOn"`
+		PPr     struct {
+			OutlineLvl *docxVal  `xml:"outlineLvl"`
+			NumPr      docxNumPr `xml:"numPr"`
+		} `xml:"pPr"`
+	} `xml:"style"`
+}
+
+type docxNumbering struct {
+	Abstract []struct {
+		ID     string `xml:"abstractNumId,attr"`
+		Levels []struct {
+			ILvl   int     `xml:"ilvl,attr"`
+			NumFmt docxVal `xml:"numFmt"`
+		} `xml:"lvl"`
+	} `xml:"abstractNum"`
+	Nums []struct {
+		ID       string  `xml:"numId,attr"`
+		Abstract docxVal `xml:"abstractNumId"`
+	} `xml:"num"`
+}
+
+// docxStyle is what a paragraph style contributes to its paragraphs.
+type docxStyle struct {
+	level int
+	numID string
+	ilvl  int
+}
+
+// docxParagraph is the state of the paragraph being read.
+type docxParagraph struct {
+	text  strings.Builder
+	style string
+	level int
+	numID string
+	ilvl  int
+}
+
+type docxTable struct {
+	rows [][]string
+	row  []string
+	cell []string
+}
+
+type docxReader struct {
+	files     map[string]*zip.File
+	styles    map[string]docxStyle
+	formats   map[string]map[int]string
+	counters  map[string][]int
+	footnotes bool
+}
+
+// readDOCX keeps the structure of a Word document: headings become "#"
+// lines of their level, so chunks carry section paths, list items get
+// their bullet or number, tables are written as Markdown and page breaks
+// start a new part. Footnotes, headers and footers follow the body when
+// enabled.
+func readDOCX(_ context.Context, data []byte, opts Options) (*Result, error) {
+	files, err := zipFiles(data)
+	if err != nil {
+		return nil, err
+	}
+	r := &docxReader{
+		files:     files,
+		counters:  make(map[string][]int),
+		footnotes: opts.Extraction.Footnotes,
+	}
+	if err := r.readStyles(); err != nil {
+		return nil, err
+	}
+	if err := r.readNumbering(); err != nil {
+		return nil, err
+	}
+
+	body, err := r.part("word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	var parts []chunker.Part
+	for i, page := range strings.Split(body, docxPageBreak) {
+		parts = append(parts, chunker.Part{Text: page, Page: i + 1})
+	}
+
+	if opts.Extraction.Footnotes {
+		notes, err := r.notes()
+		if err != nil {
+			return nil, err
+		}
+		if notes != "" {
+			parts = append(parts, chunker.Part{Text: "# Сноски\n\n" + notes})
+		}
+	}
+	if opts.Extraction.HeadersFooters {
+		rels, err := readRelationships(files, "word/document.xml")
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		var texts []string
+		for _, rel := range rels {
+			if rel.Type != headerRel && rel.Type != footerRel {
+				continue
+			}
+			text, err := r.part(rel.Target)
+			if err != nil {
+				return nil, err
+			}
+			text = strings.TrimSpace(strings.ReplaceAll(text, docxPageBreak, ""))
+			if text != "" && !seen[text] {
+				seen[text] = true
+				texts = append(texts, text)
+			}
+		}
+		if len(texts) > 0 {
+			parts = append(parts, chunker.Part{Text: "# Колонтитулы\n\n" + strings.Join(texts, "\n\n")})
+		}
+	}
+	return &Result{Chunks: chunker.Split(parts, opts.Chunking)}, nil
+}
+
+func (r *docxReader) readStyles() error {
+	r.styles = make(map[string]docxStyle)
+	if _, ok := r.files["word/styles.xml"]; !ok {
+		return nil
+	}
+	var styles docxStyles
+	if err := readZipXML(r.files, "word/styles.xml", &styles); err != nil {
+		return err
+	}
+	type raw struct {
+		basedOn string
+		style   docxStyle
+		hasLvl  bool
+	}
+	all := make(map[string]raw, len(styles.Styles))
+	for _, s := range styles.Styles {
+		st := raw{basedOn: s.BasedOn.Val, style: docxStyle{ilvl: -1}}
+		if m := headingStyle.FindStringSubmatch(strings.ToLower(s.Name.Val)); m != nil {
+			st.style.level, _ = strconv.Atoi(m[1])
+			st.hasLvl = true
+		} else if strings.EqualFold(s.Name.Val, "title") {
+			st.style.level, st.hasLvl = 1, true
+		}
+		if s.PPr.OutlineLvl != nil {
+			if lvl, err := strconv.Atoi(s.PPr.OutlineLvl.Val); err == nil {
+				st.style.level, st.hasLvl = outlineLevel(lvl), true
+			}
+		}
+		if s.PPr.NumPr.NumID != nil {
+			st.style.numID = s.PPr.NumPr.NumID.Val
+		}
+		if s.PPr.NumPr.ILvl != nil {
+			st.style.ilvl, _ = strconv.Atoi(s.PPr.NumPr.ILvl.Val)
+		}
+		all[s.ID] = st
+	}
+	// Styles inherit what they leave unset from the style they are based on.
+	for id, st := range all {
+		resolved := st.style
+		hasLvl := st.hasLvl
+		for next, depth := st.basedOn, 0; next != "" && depth < 10; depth++ {
+			base, ok := all[next]
+			if !ok {
+				break
+			}
+			if !hasLvl && base.hasLvl {
+				resolved.level, hasLvl = base.style.level, true
+			}
+			if resolved.numID == "" {
+				resolved.numID = base.style.numID
+			}
+			if resolved.ilvl < 0 {
+				resolved.ilvl = base.style.ilvl
+			}
+			next = base.basedOn
+		}
+		r.styles[id] = resolved
+	}
+	return nil
+}
+
+func (r *docxReader) readNumbering() error {
+	r.formats = make(map[string]map[int]string)
+	if _, ok := r.files["word/numbering.xml"]; !ok {
+		return nil
+	}
+	var numbering docxNumbering
+	if err := readZipXML(r.files, "word/numbering.xml", &numbering); err != nil {
+		return err
+	}
+	abstract := make(map[string]map[int]string, len(numbering.Abstract))
+	for _, a := range numbering.Abstract {
+		levels := make(map[int]string, len(a.Levels))
+		for _, l := range a.Levels {
+			levels[l.ILvl] = l.NumFmt.Val
+		}
+		abstract[a.ID] = levels
+	}
+	for _, n := range numbering.Nums {
+		r.formats[n.ID] = abstract[n.Abstract.Val]
+	}
+	return nil
+}
+
+// outlineLevel converts a zero-based outline level to a heading level; 9
+// is body text.
+func outlineLevel(lvl int) int {
+	if lvl < 0 || lvl > 8 {
+		return 0
+	}
+	return min(lvl+1, 6)
+}
+
+// part renders a document, header or footer part as Markdown, with
+// docxPageBreak where the document breaks the page.
+func (r *docxReader) part(name string) (string, error) {
+	rc, err := openZipFile(r.files, name)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	var (
+		out      strings.Builder
+		para     *docxParagraph
+		depth    int
+		inText   bool
+		skip     int
+		tables   []*docxTable
+		lastList bool
+	)
+	emit := func() {
+		text := strings.TrimSpace(para.text.String())
+		para.text.Reset()
+		if text == "" {
+			return
+		}
+		if len(tables) > 0 {
+			t := tables[len(tables)-1]
+			t.cell = append(t.cell, text)
+			return
+		}
+		level, numID, ilvl := r.paragraphStyle(para)
+		switch {
+		case level > 0:
+			if lastList {
+				out.WriteString("\n")
+			}
+			out.WriteString(strings.Repeat("#", level) + " " + text + "\n\n")
+			lastList = false
+		case numID != "" && numID != "0":
+			out.WriteString(strings.Repeat("  ", ilvl) + r.listMarker(numID, ilvl) + text + "\n")
+			lastList = true
+		default:
+			if lastList {
+				out.WriteString("\n")
+			}
+			out.WriteString(text + "\n\n")
+			lastList = false
+		}
+	}
+
+	decoder := xml.NewDecoder(rc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if skip > 0 {
+				skip++
+				continue
+			}
+			if t.Name.Space == compatNS && t.Name.Local == "Fallback" {
+				skip++
+				continue
+			}
+			if t.Name.Space != wordNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "del", "instrText":
+				skip++
+			case "p":
+				depth++
+				if depth == 1 {
+					para = &docxParagraph{ilvl: -1}
+				} else {
+					para.text.WriteString(" ")
+				}
+			case "pStyle":
+				if para != nil && depth == 1 {
+					para.style = xmlAttr(t, "val")
+				}
+			case "outlineLvl":
+				if para != nil && depth == 1 {
+					if lvl, err := strconv.Atoi(xmlAttr(t, "val")); err == nil {
+						para.level = outlineLevel(lvl)
+					}
+				}
+			case "numId":
+				if para != nil && depth == 1 {
+					para.numID = xmlAttr(t, "val")
+				}
+			case "ilvl":
+				if para != nil && depth == 1 {
+					para.ilvl, _ = strconv.Atoi(xmlAttr(t, "val"))
+				}
+			case "t":
+				inText = true
+			case "tab":
+				if para != nil && depth > 0 {
+					para.text.WriteString("\t")
+				}
+			case "br", "cr":
+				if para == nil || depth == 0 {
+					continue
+				}
+				if xmlAttr(t, "type") == "page" && len(tables) == 0 {
+					emit()
+					out.WriteString(docxPageBreak)
+				} else {
+					para.text.WriteString("\n")
+				}
+			case "footnoteReference":
+				if para != nil && r.footnotes {
+					para.text.WriteString("[^" + xmlAttr(t, "id") + "]")
+				}
+			case "tbl":
+				if len(tables) == 0 && lastList {
+					out.WriteString("\n")
+					lastList = false
+				}
+				tables = append(tables, &docxTable{})
+			case "tr":
+				if len(tables) > 0 {
+					tables[len(tables)-1].row = nil
+				}
+			case "tc":
+				if len(tables) > 0 {
+					tables[len(tables)-1].cell = nil
+				}
+			}
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if t.Name.Space != wordNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				depth--
+				if depth == 0 && para != nil {
+					emit()
+					para = nil
+				}
+			case "tc":
+				if len(tables) > 0 {
+					tb := tables[len(tables)-1]
+					cell := strings.Join(strings.Fields(strings.Join(tb.cell, " ")), " ")
+					tb.row = append(tb.row, strings.ReplaceAll(cell, "|", `\|`))
+				}
+			case "tr":
+				if len(tables) > 0 {
+					tb := tables[len(tables)-1]
+					if !isEmptyRow(tb.row) {
+						tb.rows = append(tb.rows, tb.row)
+					}
+				}
+			case "tbl":
+				if len(tables) == 0 {
+					continue
+				}
+				tb := tables[len(tables)-1]
+				tables = tables[:len(tables)-1]
+				if len(tables) > 0 {
+					// A nested table is flattened into the enclosing cell.
+					parent := tables[len(tables)-1]
+					for _, row := range tb.rows {
+						parent.cell = append(parent.cell, strings.Join(row, " "))
+					}
+					continue
+				}
+				out.WriteString(markdownTable(tb.rows))
+			}
+		case xml.CharData:
+			if inText && skip == 0 && para != nil {
+				para.text.Write(t)
+			}
+		}
+	}
+	return out.String(), nil
+}
+
+// paragraphStyle resolves the heading level and list of a paragraph from
+// its own properties and its style.
+func (r *docxReader) paragraphStyle(p *docxParagraph) (level int, numID string, ilvl int) {
+	style := r.styles[p.style]
+	level, numID, ilvl = style.level, style.numID, style.ilvl
+	if p.level > 0 {
+		level = p.level
+	}
+	if p.numID != "" {
+		numID = p.numID
+	}
+	if p.ilvl >= 0 {
+		ilvl = p.ilvl
+	}
+	return level, numID, max(ilvl, 0)
+}
+
+// listMarker numbers list items the way Word shows them, restarting
+// deeper levels when a shallower one advances.
+func (r *docxReader) listMarker(numID string, ilvl int) string {
+	format := r.formats[numID][ilvl]
+	if format == "bullet" || format == "none" || format == "" {
+		return "- "
+	}
+	counters := r.counters[numID]
+	for len(counters) <= ilvl {
+		counters = append(counters, 0)
+	}
+	counters[ilvl]++
+	for i := ilvl + 1; i < len(counters); i++ {
+		counters[i] = 0
+	}
+	r.counters[numID] = counters
+	return strconv.Itoa(counters[ilvl]) + ". "
+}
+
+// notes collects the footnotes as Markdown footnote definitions.
+func (r *docxReader) notes() (string, error) {
+	if _, ok := r.files["word/footnotes.xml"]; !ok {
+		return "", nil
+	}
+	var footnotes struct {
+		Notes []struct {
+			ID    string `xml:"id,attr"`
+			Type  string `xml:"type,attr"`
+			Paras []struct {
+				Texts []string `xml:"r>t"`
+			} `xml:"p"`
+		} `xml:"footnote"`
+	}
+	if err := readZipXML(r.files, "word/footnotes.xml", &footnotes); err != nil {
+		return "", err
+	}
+	var lines []string
+	for _, note := range footnotes.Notes {
+		if note.Type != "" {
+			continue
+		}
+		var paras []string
+		for _, p := range note.Paras {
+			if text := strings.TrimSpace(strings.Join(p.Texts, "")); text != "" {
+				paras = append(paras, text)
+			}
+		}
+		if len(paras) > 0 {
+			lines = append(lines, "[^"+note.ID+"]: "+strings.Join(paras, " "))
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// markdownTable writes rows as a Markdown table with the first row as the
+// header.
+func markdownTable(rows [][]string) string {
+	if len(rows) == 0 {
+		return ""
+	}
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,131 @@
+package doc
+
+import (
+	"ai-service/internal/util/config"
+	"archive/zip"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+const docxHead = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`
+
+func zipOf(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func para(style, text string) string {
+	props := ""
+	if style != "" {
+		props = `<w:pPr><w:pStyle w:val="` + style + `"/></w:pPr>`
+	}
+	return `<w:p>` + props + `<w:r><w:t>` + text + `</w:t></w:r></w:p>`
+}
+
+func listItem(numID, ilvl, text string) string {
+	return `<w:p><w:pPr><w:numPr><w:ilvl w:val="` + ilvl + `"/><w:numId w:val="` + numID + `"/></w:numPr></w:pPr><w:r><w:t>` + text + `</w:t></w:r></w:p>`
+}
+
+var docxStylesXML = `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:style w:styleId="Heading1"><w:name w:val="heading 1"/></w:style>
+<w:style w:styleId="a1"><w:name w:val="Заголовок 2"/></w:style>
+<w:style w:styleId="Custom"><w:name w:val="My Heading"/><w:basedOn w:val="Heading1"/></w:style>
+</w:styles>`
+
+var docxNumberingXML = `<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:abstractNum w:abstractNumId="0"><w:lvl w:ilvl="0"><w:numFmt w:val="decimal"/></w:lvl><w:lvl w:ilvl="1"><w:numFmt w:val="decimal"/></w:lvl></w:abstractNum>
+<w:abstractNum w:abstractNumId="1"><w:lvl w:ilvl="0"><w:numFmt w:val="bullet"/></w:lvl></w:abstractNum>
+<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>
+<w:num w:numId="2"><w:abstractNumId w:val="1"/></w:num>
+</w:numbering>`
+
+func docxBody(t *testing.T, body string) string {
+	t.Helper()
+	files := zipOf(t, map[string]string{
+		"word/document.xml":  docxHead + body + `</w:body></w:document>`,
+		"word/styles.xml":    docxStylesXML,
+		"word/numbering.xml": docxNumberingXML,
+	})
+	zf, err := zipFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &docxReader{files: zf, counters: map[string][]int{}}
+	if err := r.readStyles(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.readNumbering(); err != nil {
+		t.Fatal(err)
+	}
+	text, err := r.part("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return text
+}
+
+func TestDOCXStructure(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{"headings", para("Heading1", "Глава") + para("a1", "Раздел") + para("Custom", "Унаследован") + para("", "Текст"),
+			"# Глава\n\n## Раздел\n\n# Унаследован\n\nТекст\n\n"},
+		{"numbered list", listItem("1", "0", "один") + listItem("1", "1", "вложенный") + listItem("1", "0", "два") + para("", "После"),
+			"1. один\n  1. вложенный\n2. два\n\nПосле\n\n"},
+		{"bullets", listItem("2", "0", "пункт") + listItem("2", "0", "ещё"),
+			"- пункт\n- ещё\n"},
+		{"table", `<w:tbl><w:tr><w:tc>` + para("", "Тариф") + `</w:tc><w:tc>` + para("", "Цена") + `</w:tc></w:tr>` +
+			`<w:tr><w:tc>` + para("", "A|B") + `</w:tc><w:tc>` + para("", "100") + `</w:tc></w:tr></w:tbl>`,
+			"| Тариф | Цена |\n| --- | --- |\n| A\\|B | 100 |\n"},
+		{"deleted text", `<w:p><w:r><w:t>Оставить</w:t></w:r><w:del><w:r><w:delText>убрать</w:delText></w:r></w:del></w:p>`,
+			"Оставить\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := docxBody(t, tt.body); !strings.Contains(got, tt.want) {
+				t.Errorf("got %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadDOCXPagesAndSections(t *testing.T) {
+	body := para("Heading1", "Введение") + para("", "Первая страница.") +
+		`<w:p><w:r><w:br w:type="page"/></w:r></w:p>` +
+		para("Heading1", "Итоги") + para("", "Вторая страница.")
+	data := zipOf(t, map[string]string{
+		"word/document.xml": docxHead + body + `</w:body></w:document>`,
+		"word/styles.xml":   docxStylesXML,
+	})
+	result, err := readDOCX(context.Background(), data, Options{Chunking: config.Chunking{Size: 1000, Unit: "char"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := result.Chunks
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks, want 2: %q", len(chunks), chunks)
+	}
+	if chunks[0].Section != "Введение" || chunks[0].Page != 1 {
+		t.Errorf("first chunk: section %q page %d", chunks[0].Section, chunks[0].Page)
+	}
+	if chunks[1].Section != "Итоги" || chunks[1].Page != 2 || !strings.Contains(chunks[1].Text, "Вторая страница.") {
+		t.Errorf("second chunk: %+v", chunks[1])
+	}
+}
@@ -0,0 +1,87 @@
+package doc
+
+import (
+	"ai-service/internal/util/chunker"
+	"errors"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const epubType = "application/epub+zip"
+
+// readEPUB reads the chapters of an e-book in reading order, each as a part
+// numbered like the chapter.
+func readEPUB(data []byte) ([]chunker.Part, error) {
+	files, err := zipFiles(data)
+	if err != nil {
+		return nil, err
+	}
+	var container struct {
+		Rootfiles []struct {
+			Path string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := readZipXML(files, "META-INF/container.xml", &container); err != nil {
+		return nil, err
+	}
+	if len(container.Rootfiles) == 0 {
+		return nil, errors.New("could not find the package document in EPUB file")
+	}
+	opf := container.Rootfiles[0].Path
+
+	var pkg struct {
+		Items []struct {
+			ID         string `xml:"id,attr"`
+			Href       string `xml:"href,attr"`
+			MediaType  string `xml:"media-type,attr"`
+			Properties string `xml:"properties,attr"`
+		} `xml:"manifest>item"`
+		Spine []struct {
+			IDRef  string `xml:"idref,attr"`
+			Linear string `xml:"linear,attr"`
+		} `xml:"spine>itemref"`
+	}
+	if err := readZipXML(files, opf, &pkg); err != nil {
+		return nil, err
+	}
+	dir := path.Dir(opf)
+	hrefs := make(map[string]string, len(pkg.Items))
+	for _, item := range pkg.Items {
+		if strings.Contains(item.MediaType, "html") && !strings.Contains(item.Properties, "nav") {
+			href, err := url.PathUnescape(item.Href)
+			if err != nil {
+				href = item.Href
+			}
+			hrefs[item.ID] = path.Join(dir, href)
+		}
+	}
+
+	var parts []chunker.Part
+	for _, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok || ref.Linear == "no" {
+			continue
+		}
+		rc, err := openZipFile(files, href)
+		if err != nil {
+			return nil, err
+		}
+		chapter, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		text, err := readHTML(chapter)
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range text {
+			if strings.TrimSpace(part.Text) != "" {
+				parts = append(parts, chunker.Part{Text: part.Text, Page: len(parts) + 1})
+			}
+		}
+	}
+	return parts, nil
+}
@@ -0,0 +1,309 @@
+package doc
+
+import (
+	"ai-service/internal/util/chunker"
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// boilerplate elements carry no document content.
+var boilerplate = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Nav:      true,
+	atom.Header:   true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Iframe:   true,
+	atom.Svg:      true,
+	atom.Canvas:   true,
+}
+
+var boilerplateRoles = map[string]bool{
+	"navigation":    true,
+	"banner":        true,
+	"contentinfo":   true,
+	"complementary": true,
+	"search":        true,
+}
+
+var blocks = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.Div:        true,
+	atom.Section:    true,
+	atom.Article:    true,
+	atom.Main:       true,
+	atom.Blockquote: true,
+	atom.Pre:        true,
+	atom.Ul:         true,
+	atom.Ol:         true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Dd:         true,
+	atom.Figure:     true,
+	atom.Figcaption: true,
+	atom.Address:    true,
+	atom.Hr:         true,
+}
+
+var headings = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+var (
+	whitespace = regexp.MustCompile(`\s+`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// readHTML renders the main content of a page as Markdown-like text:
+// navigation, scripts and similar boilerplate are dropped, headings become
+// "#" lines and tables become rows of cells separated by "|".
+func readHTML(data []byte) ([]chunker.Part, error) {
+	var text string
+	if e, _, certain := charset.DetermineEncoding(data, "text/html"); certain {
+		text = decodeWith(e, data)
+	} else {
+		text = decodeText(data)
+	}
+	root, err := html.Parse(strings.NewReader(text))
+	if err != nil {
+		return nil, err
+	}
+
+	r := &htmlRenderer{}
+	content := find(root, atom.Main)
+	if content == nil {
+		content = find(root, atom.Article)
+	}
+	if content == nil {
+		content = root
+	}
+	if find(content, atom.H1) == nil {
+		if title := find(root, atom.Title); title != nil {
+			if t := inlineText(title); t != "" {
+				r.block()
+				r.out.WriteString("# " + t)
+				r.block()
+			}
+		}
+	}
+	r.render(content)
+
+	out := blankLines.ReplaceAllString(r.out.String(), "\n\n")
+	return []chunker.Part{{Text: strings.TrimSpace(out)}}, nil
+}
+
+type htmlRenderer struct {
+	out bytes.Buffer
+	pre int
+}
+
+func (r *htmlRenderer) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.text(n.Data)
+		return
+	case html.ElementNode:
+		if boilerplate[n.DataAtom] || hidden(n) {
+			return
+		}
+	case html.DocumentNode:
+	default:
+		return
+	}
+
+	switch {
+	case headings[n.DataAtom] > 0:
+		if t := inlineText(n); t != "" {
+			r.block()
+			r.out.WriteString(strings.Repeat("#", headings[n.DataAtom]) + " " + t)
+			r.block()
+		}
+		return
+	case n.DataAtom == atom.Table:
+		r.block()
+		r.table(n)
+		r.block()
+		return
+	case n.DataAtom == atom.Br:
+		r.out.WriteString("\n")
+		return
+	case n.DataAtom == atom.Li:
+		r.line()
+		r.out.WriteString(listMarker(n))
+	case n.DataAtom == atom.Img:
+		if alt := attr(n, "alt"); alt != "" {
+			r.text(alt)
+		}
+		return
+	}
+
+	if blocks[n.DataAtom] {
+		r.block()
+	}
+	if n.DataAtom == atom.Pre {
+		r.pre++
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.render(c)
+	}
+	if n.DataAtom == atom.Pre {
+		r.pre--
+	}
+	if blocks[n.DataAtom] {
+		r.block()
+	}
+}
+
+// text writes s with runs of whitespace collapsed, except inside <pre>.
+func (r *htmlRenderer) text(s string) {
+	if r.pre > 0 {
+		r.out.WriteString(s)
+		return
+	}
+	s = whitespace.ReplaceAllString(s, " ")
+	if r.atLineStart() {
+		s = strings.TrimLeft(s, " ")
+	}
+	r.out.WriteString(s)
+}
+
+func (r *htmlRenderer) atLineStart() bool {
+	b := r.out.Bytes()
+	return len(b) == 0 || bytes.HasSuffix(b, []byte("\n")) || bytes.HasSuffix(b, []byte(" "))
+}
+
+// line ends the current line, if any.
+func (r *htmlRenderer) line() {
+	b := bytes.TrimRight(r.out.Bytes(), " ")
+	r.out.Truncate(len(b))
+	if len(b) > 0 && b[len(b)-1] != '\n' {
+		r.out.WriteByte('\n')
+	}
+}
+
+// block leaves a blank line before what follows.
+func (r *htmlRenderer) block() {
+	r.line()
+	if b := r.out.Bytes(); len(b) > 0 && !bytes.HasSuffix(b, []byte("\n\n")) {
+		r.out.WriteByte('\n')
+	}
+}
+
+// table writes one line per row. A header row is followed by a Markdown
+// separator so the cells below read as records.
+func (r *htmlRenderer) table(n *html.Node) {
+	var rows [][]string
+	header := false
+	walk(n, func(c *html.Node) bool {
+		if c != n && c.DataAtom == atom.Table {
+			return false
+		}
+		if c.DataAtom != atom.Tr {
+			return true
+		}
+		var cells []string
+		allHeaders := true
+		for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.DataAtom != atom.Td && cell.DataAtom != atom.Th {
+				continue
+			}
+			allHeaders = allHeaders && cell.DataAtom == atom.Th
+			cells = append(cells, strings.ReplaceAll(inlineText(cell), "|", `\|`))
+		}
+		if len(cells) > 0 {
+			if len(rows) == 0 {
+				header = allHeaders
+			}
+			rows = append(rows, cells)
+		}
+		return false
+	})
+	for i, cells := range rows {
+		r.out.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 && header {
+			r.out.WriteString("|" + strings.Repeat(" --- |", len(cells)) + "\n")
+		}
+	}
+}
+
+func listMarker(li *html.Node) string {
+	if li.Parent == nil || li.Parent.DataAtom != atom.Ol {
+		return "- "
+	}
+	n := 1
+	for s := li.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.DataAtom == atom.Li {
+			n++
+		}
+	}
+	return strconv.Itoa(n) + ". "
+}
+
+// inlineText is the visible text of n on a single line.
+func inlineText(n *html.Node) string {
+	r := &htmlRenderer{}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.render(c)
+	}
+	return strings.TrimSpace(whitespace.ReplaceAllString(r.out.String(), " "))
+}
+
+func hidden(n *html.Node) bool {
+	if _, ok := attrValue(n, "hidden"); ok {
+		return true
+	}
+	return attr(n, "aria-hidden") == "true" || boilerplateRoles[attr(n, "role")]
+}
+
+func attr(n *html.Node, key string) string {
+	v, _ := attrValue(n, key)
+	return v
+}
+
+func attrValue(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func find(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walk(n, func(c *html.Node) bool {
+		if found != nil {
+			return false
+		}
+		if c.Type == html.ElementNode && c.DataAtom == a {
+			found = c
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// walk calls fn for n and its descendants in document order, skipping the
+// children of nodes for which fn returns false.
+func walk(n *html.Node, fn func(*html.Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
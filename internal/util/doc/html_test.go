@@ -0,0 +1,77 @@
+package doc
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestReadHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		want     []string
+		excluded []string
+	}{
+		{
+			name: "main content",
+			in: `<html><head><title>Сайт</title><script>var x = 1;</script></head><body>
+				<nav><a href="/">Главная</a></nav>
+				<main><h1>Тарифы</h1><p>Первый   абзац.</p><h2>Цены</h2><ul><li>один</li><li>два</li></ul></main>
+				<footer>© 2024</footer></body></html>`,
+			want:     []string{"# Тарифы", "Первый абзац.", "## Цены", "один", "два"},
+			excluded: []string{"Главная", "var x", "© 2024", "# Сайт"},
+		},
+		{
+			name: "title as heading",
+			in:   `<html><head><title>Справка</title></head><body><p>Текст.</p></body></html>`,
+			want: []string{"# Справка", "Текст."},
+		},
+		{
+			name: "table",
+			in:   `<table><tr><th>Тариф</th><th>Цена</th></tr><tr><td>Базовый</td><td>100</td></tr></table>`,
+			want: []string{"Тариф | Цена", "Базовый | 100"},
+		},
+		{
+			name:     "hidden",
+			in:       `<p>Видно</p><div hidden>Скрыто</div><div aria-hidden="true">Тоже</div>`,
+			want:     []string{"Видно"},
+			excluded: []string{"Скрыто", "Тоже"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := readHTML([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			text := parts[0].Text
+			for _, w := range tt.want {
+				if !strings.Contains(text, w) {
+					t.Errorf("text %q lacks %q", text, w)
+				}
+			}
+			for _, x := range tt.excluded {
+				if strings.Contains(text, x) {
+					t.Errorf("text %q contains %q", text, x)
+				}
+			}
+		})
+	}
+}
+
+func TestReadHTMLCharset(t *testing.T) {
+	page := `<html><head><meta charset="windows-1251"></head><body><p>Привет, мир</p></body></html>`
+	data, err := charmap.Windows1251.NewEncoder().Bytes([]byte(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts, err := readHTML(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parts[0].Text != "Привет, мир" {
+		t.Errorf("text %q, want the page decoded as windows-1251", parts[0].Text)
+	}
+}
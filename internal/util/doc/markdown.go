@@ -0,0 +1,78 @@
+package doc
+
+import (
+	"ai-service/internal/util/chunker"
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownHint = regexp.MustCompile("(?m)^(#{1,6}[ \t]+\\S|```|~~~|={3,}[ \t]*$)|\\[[^\\]\\n]+\\]\\([^)\\s]+\\)")
+	atxHeading   = regexp.MustCompile(`^ {0,3}(#{1,6})[ \t]+\S`)
+	setextLine   = regexp.MustCompile(`^ {0,3}(=+|-{2,})[ \t]*$`)
+	fence        = regexp.MustCompile("^ {0,3}(```|~~~)")
+	imageLink    = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+)
+
+// looksLikeMarkdown tells Markdown from plain text, which sniff the same.
+func looksLikeMarkdown(data []byte) bool {
+	return markdownHint.Match(data)
+}
+
+// readMarkdown keeps the Markdown source but brings headings to the ATX
+// form the chunker starts sections at: setext headings are rewritten,
+// "#" lines inside code blocks are indented so they are not taken for
+// headings, front matter is dropped and images are reduced to their alt
+// text.
+func readMarkdown(data []byte) ([]chunker.Part, error) {
+	lines := strings.Split(stripFrontMatter(decodeText(data)), "\n")
+	out := make([]string, 0, len(lines))
+	var inFence string
+	for _, line := range lines {
+		if m := fence.FindStringSubmatch(line); m != nil {
+			switch {
+			case inFence == "":
+				inFence = m[1]
+			case inFence == m[1]:
+				inFence = ""
+			}
+			out = append(out, line)
+			continue
+		}
+		if inFence != "" {
+			if strings.HasPrefix(line, "#") {
+				line = "    " + line
+			}
+			out = append(out, line)
+			continue
+		}
+		if m := setextLine.FindStringSubmatch(line); m != nil && len(out) > 0 {
+			prev := strings.TrimSpace(out[len(out)-1])
+			if prev != "" && !atxHeading.MatchString(prev) {
+				level := "##"
+				if strings.HasPrefix(m[1], "=") {
+					level = "#"
+				}
+				out[len(out)-1] = level + " " + prev
+				continue
+			}
+		}
+		if atxHeading.MatchString(line) {
+			line = strings.TrimLeft(line, " ")
+		}
+		out = append(out, imageLink.ReplaceAllString(line, "$1"))
+	}
+	return []chunker.Part{{Text: strings.Join(out, "\n")}}, nil
+}
+
+// stripFrontMatter drops a leading YAML block delimited by "---" lines.
+func stripFrontMatter(text string) string {
+	if !strings.HasPrefix(text, "---\n") {
+		return text
+	}
+	end := strings.Index(text[4:], "\n---\n")
+	if end < 0 {
+		return text
+	}
+	return text[4+end+5:]
+}
@@ -0,0 +1,52 @@
+package doc
+
+import (
+	"testing"
+)
+
+func TestReadMarkdown(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"setext", "Заголовок\n=========\nТекст\n\nРаздел\n------\nЕщё", "# Заголовок\nТекст\n\n## Раздел\nЕщё"},
+		{"setext needs a line above", "\n---\nТекст", "\n---\nТекст"},
+		{"atx is kept", "  ## Раздел\nТекст", "## Раздел\nТекст"},
+		{"underline under atx", "# Раздел\n===", "# Раздел\n==="},
+		{"fenced comment", "```sh\n# install\nmake\n```\n# Раздел", "```sh\n    # install\nmake\n```\n# Раздел"},
+		{"tilde fence", "~~~\n# not a heading\n```\n# still code\n~~~", "~~~\n    # not a heading\n```\n    # still code\n~~~"},
+		{"no setext in fence", "```\ncode\n---\n```", "```\ncode\n---\n```"},
+		{"front matter", "---\ntitle: x\n---\n# Раздел", "# Раздел"},
+		{"image", "См. ![схема сети](img/net.png).", "См. схема сети."},
+		{"link is kept", "[сайт](https://example.kz)", "[сайт](https://example.kz)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := readMarkdown([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parts) != 1 || parts[0].Text != tt.want {
+				t.Errorf("readMarkdown(%q) = %q, want %q", tt.in, parts, tt.want)
+			}
+		})
+	}
+}
+
+func TestLooksLikeMarkdown(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"# Заголовок\nтекст", true},
+		{"текст\n```\ncode\n```", true},
+		{"Заголовок\n===", true},
+		{"см. [сайт](https://example.kz)", true},
+		{"#hashtag и обычный текст", false},
+		{"Просто текст.\nВторая строка.", false},
+	}
+	for _, tt := range tests {
+		if got := looksLikeMarkdown([]byte(tt.in)); got != tt.want {
+			t.Errorf("looksLikeMarkdown(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package doc
+
+import (
+	"ai-service/internal/service/document"
+	"ai-service/internal/util/chunker"
+	"bytes"
+	"context"
+	"fmt"
+	"image/png"
+
+	"golang.org/x/image/tiff"
+)
+
+// OCR recognises the text in a picture, given as PNG or JPEG.
+type OCR interface {
+	Recognize(ctx context.Context, image []byte, mediaType string) (string, error)
+}
+
+const (
+	pngType  = "image/png"
+	jpegType = "image/jpeg"
+	tiffType = "image/tiff"
+)
+
+// ocrMinSide is the size in pixels below which pictures are taken for
+// icons and logos and not recognised.
+const ocrMinSide = 100
+
+// readImage recognises the text of an uploaded picture. TIFF is converted
+// to PNG; only its first page is read.
+func readImage(ctx context.Context, data []byte, opts Options) (*Result, error) {
+	report := document.Report{Pages: 1}
+	if opts.OCR == nil {
+		report.ImageOnlyPages = []int{1}
+		return &Result{Report: report}, nil
+	}
+	mediaType := detectType(data)
+	if mediaType == tiffType {
+		img, err := tiff.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		var b bytes.Buffer
+		if err := png.Encode(&b, img); err != nil {
+			return nil, err
+		}
+		data, mediaType = b.Bytes(), pngType
+	}
+	text, err := opts.OCR.Recognize(ctx, data, mediaType)
+	if err != nil {
+		return nil, fmt.Errorf("ocr: %w", err)
+	}
+	report.OCRPages = []int{1}
+	parts := []chunker.Part{{Text: text, Page: 1}}
+	return &Result{Chunks: chunker.Split(parts, opts.Chunking), Report: report}, nil
+}
@@ -0,0 +1,189 @@
+package doc
+
+import (
+	"ai-service/internal/util/config"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/tiff"
+)
+
+// fakeOCR returns text for every picture and records what it was given.
+type fakeOCR struct {
+	text   string
+	err    error
+	images [][]byte
+	types  []string
+}
+
+func (o *fakeOCR) Recognize(_ context.Context, image []byte, mediaType string) (string, error) {
+	o.images = append(o.images, image)
+	o.types = append(o.types, mediaType)
+	return o.text, o.err
+}
+
+var ocrChunking = config.Chunking{Size: 1000, Unit: "char"}
+
+func pngOf(t *testing.T, side int) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewGray(image.Rect(0, 0, side, side))); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// scanPDF builds a one-page PDF that draws a single image stored with
+// filter, or a page of text when image is empty.
+func scanPDF(side int, filter string, image []byte, text string) []byte {
+	content := "q 200 0 0 200 100 400 cm /Im1 Do Q"
+	if text != "" {
+		content = pdfLinesAt(72, text)
+	}
+	return pdfFile([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [5 0 R] /Count 1 >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> /XObject << /Im1 6 0 R >> >> /Contents 7 0 R >>",
+		fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /%s /Length %d >>\nstream\n%s\nendstream",
+			side, side, filter, len(image), image),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	})
+}
+
+func flateGray(t *testing.T, side int) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	if _, err := w.Write(bytes.Repeat([]byte{0x80}, side*side)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestReadImage(t *testing.T) {
+	picture := pngOf(t, 200)
+	ocr := &fakeOCR{text: "Счёт № 15"}
+	result, err := Read(context.Background(), picture, "scan.png", Options{Chunking: ocrChunking, OCR: ocr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Chunks) != 1 || result.Chunks[0].Text != "Счёт № 15" || result.Chunks[0].Page != 1 {
+		t.Errorf("chunks %+v", result.Chunks)
+	}
+	if !reflect.DeepEqual(result.Report.OCRPages, []int{1}) {
+		t.Errorf("OCR pages %v, want [1]", result.Report.OCRPages)
+	}
+	if !bytes.Equal(ocr.images[0], picture) || ocr.types[0] != pngType {
+		t.Errorf("OCR got a %s picture of %d bytes, want the PNG as is", ocr.types[0], len(ocr.images[0]))
+	}
+}
+
+func TestReadImageConvertsTIFF(t *testing.T) {
+	var b bytes.Buffer
+	if err := tiff.Encode(&b, image.NewGray(image.Rect(0, 0, 120, 80)), nil); err != nil {
+		t.Fatal(err)
+	}
+	ocr := &fakeOCR{text: "Акт"}
+	if _, err := Read(context.Background(), b.Bytes(), "scan.tiff", Options{Chunking: ocrChunking, OCR: ocr}); err != nil {
+		t.Fatal(err)
+	}
+	if ocr.types[0] != pngType {
+		t.Fatalf("OCR got %s, want PNG", ocr.types[0])
+	}
+	img, err := png.Decode(bytes.NewReader(ocr.images[0]))
+	if err != nil || img.Bounds().Dx() != 120 || img.Bounds().Dy() != 80 {
+		t.Errorf("converted picture %v, %v", img.Bounds(), err)
+	}
+}
+
+func TestReadImageWithoutOCR(t *testing.T) {
+	_, err := Read(context.Background(), pngOf(t, 200), "scan.png", Options{Chunking: ocrChunking})
+	if !errors.Is(err, ErrNoText) || !strings.Contains(err.Error(), "страницы 1 ") {
+		t.Errorf("got %v, want ErrNoText naming page 1", err)
+	}
+	_, err = Read(context.Background(), pngOf(t, 200), "scan.png", Options{Chunking: ocrChunking, OCR: &fakeOCR{err: errors.New("down")}})
+	if err == nil || !strings.Contains(err.Error(), "ocr: down") {
+		t.Errorf("got %v, want the OCR error", err)
+	}
+}
+
+func TestReadPDFRecognisesScans(t *testing.T) {
+	jpeg := []byte("\xff\xd8\xff\xe0 not really a jpeg \xff\xd9")
+	tests := []struct {
+		name     string
+		data     []byte
+		ocr      *fakeOCR
+		text     string
+		ocrPages []int
+		types    []string
+	}{
+		{"flate samples", scanPDF(100, "FlateDecode", flateGray(t, 100), ""), &fakeOCR{text: "Скан"}, "Скан", []int{1}, []string{pngType}},
+		{"jpeg", scanPDF(150, "DCTDecode", jpeg, ""), &fakeOCR{text: "Фото"}, "Фото", []int{1}, []string{jpegType}},
+		{"page with text", scanPDF(150, "DCTDecode", jpeg, "Printed text."), &fakeOCR{text: "Фото"}, "Printed text.", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Read(context.Background(), tt.data, "scan.pdf", Options{Chunking: ocrChunking, OCR: tt.ocr})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Chunks) != 1 || result.Chunks[0].Text != tt.text {
+				t.Errorf("chunks %+v, want %q", result.Chunks, tt.text)
+			}
+			if !reflect.DeepEqual(result.Report.OCRPages, tt.ocrPages) || !reflect.DeepEqual(tt.ocr.types, tt.types) {
+				t.Errorf("OCR pages %v of types %v, want %v of %v", result.Report.OCRPages, tt.ocr.types, tt.ocrPages, tt.types)
+			}
+		})
+	}
+	ocr := &fakeOCR{}
+	if _, err := readPDF(context.Background(), scanPDF(150, "DCTDecode", jpeg, ""), Options{Chunking: ocrChunking, OCR: ocr}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ocr.images[0], jpeg) {
+		t.Errorf("OCR got %q, want the stored JPEG", ocr.images[0])
+	}
+}
+
+func TestReadPDFScanFallbacks(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []byte
+		ocr        OCR
+		imageOnly  []int
+		failed     int
+		recognised int
+	}{
+		{"without OCR", scanPDF(100, "FlateDecode", flateGray(t, 100), ""), nil, []int{1}, 0, 0},
+		{"nothing recognised", scanPDF(100, "FlateDecode", flateGray(t, 100), ""), &fakeOCR{text: "  "}, []int{1}, 0, 1},
+		{"OCR failure", scanPDF(100, "FlateDecode", flateGray(t, 100), ""), &fakeOCR{err: errors.New("down")}, nil, 1, 1},
+		{"icon only", scanPDF(32, "FlateDecode", flateGray(t, 32), ""), &fakeOCR{text: "лого"}, []int{1}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := readPDF(context.Background(), tt.data, Options{Chunking: ocrChunking, OCR: tt.ocr})
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := result.Report
+			if len(result.Chunks) != 0 || !reflect.DeepEqual(report.ImageOnlyPages, tt.imageOnly) || len(report.FailedPages) != tt.failed {
+				t.Errorf("chunks %d, image-only pages %v, failed pages %+v", len(result.Chunks), report.ImageOnlyPages, report.FailedPages)
+			}
+			if ocr, ok := tt.ocr.(*fakeOCR); ok && len(ocr.images) != tt.recognised {
+				t.Errorf("OCR called %d times, want %d", len(ocr.images), tt.recognised)
+			}
+		})
+	}
+}
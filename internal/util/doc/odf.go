@@ -0,0 +1,289 @@
+package doc
+
+import (
+	"ai-service/internal/util/chunker"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	odtType = "application/vnd.oasis.opendocument.text"
+	odsType = "application/vnd.oasis.opendocument.spreadsheet"
+)
+
+// odfSkipped elements hold footnotes, comments and deleted text.
+var odfSkipped = map[string]bool{
+	"note":            true,
+	"annotation":      true,
+	"tracked-changes": true,
+	"sequence-decls":  true,
+}
+
+// odsMaxRepeat caps repeated rows and cells; blank ones are repeated up to
+// the edge of the sheet.
+const odsMaxRepeat = 1000
+
+// readODT reads the body of an OpenDocument text. Headings become "#"
+// lines of their outline level, list items get a bullet, tables become
+// rows of cells separated by "|" and soft page breaks start a new part.
+func readODT(data []byte) ([]chunker.Part, error) {
+	files, err := zipFiles(data)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := openZipFile(files, "content.xml")
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var (
+		parts     []chunker.Part
+		out       strings.Builder
+		para      strings.Builder
+		prefix    string
+		skip      int
+		paraDepth int
+		bullet    bool
+		cell      []string
+		row       []string
+		inCell    bool
+		inHeader  bool
+		header    int
+	)
+	flushPage := func() {
+		parts = append(parts, chunker.Part{Text: out.String(), Page: len(parts) + 1})
+		out.Reset()
+	}
+	decoder := xml.NewDecoder(rc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if skip > 0 || odfSkipped[t.Name.Local] {
+				skip++
+				continue
+			}
+			switch t.Name.Local {
+			case "h", "p":
+				paraDepth++
+				if paraDepth > 1 {
+					continue
+				}
+				para.Reset()
+				prefix = ""
+				if t.Name.Local == "h" {
+					level := 1
+					if v, err := strconv.Atoi(xmlAttr(t, "outline-level")); err == nil && v > 0 {
+						level = min(v, 6)
+					}
+					prefix = strings.Repeat("#", level) + " "
+				} else if bullet && !inCell {
+					prefix = "- "
+					bullet = false
+				}
+			case "list-item":
+				bullet = true
+			case "table-row":
+				row = nil
+			case "table-header-rows":
+				inHeader = true
+			case "table-cell", "covered-table-cell":
+				inCell = true
+				cell = nil
+			case "s":
+				n, err := strconv.Atoi(xmlAttr(t, "c"))
+				if err != nil || n < 1 {
+					n = 1
+				}
+				para.WriteString(strings.Repeat(" ", n))
+			case "tab":
+				para.WriteString("\t")
+			case "line-break":
+				para.WriteString("\n")
+			case "soft-page-break":
+				flushPage()
+			}
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			switch t.Name.Local {
+			case "h", "p":
+				paraDepth--
+				if paraDepth > 0 {
+					continue
+				}
+				text := strings.TrimSpace(para.String())
+				switch {
+				case inCell:
+					if text != "" {
+						cell = append(cell, text)
+					}
+				case text != "":
+					out.WriteString(prefix + text + "\n\n")
+				}
+			case "list-item":
+				bullet = false
+			case "table-cell", "covered-table-cell":
+				inCell = false
+				row = append(row, strings.ReplaceAll(strings.Join(cell, " "), "|", `\|`))
+			case "table-row":
+				if !isEmptyRow(row) {
+					out.WriteString("| " + strings.Join(row, " | ") + " |\n")
+					if inHeader {
+						header = len(row)
+					}
+				}
+			case "table-header-rows":
+				// The separator marks the rows above as the header.
+				if header > 0 {
+					out.WriteString("|" + strings.Repeat(" --- |", header) + "\n")
+				}
+				inHeader, header = false, 0
+			case "table":
+				out.WriteString("\n")
+			}
+		case xml.CharData:
+			if skip == 0 && paraDepth > 0 {
+				para.Write(t)
+			}
+		}
+	}
+	flushPage()
+	return parts, nil
+}
+
+// readODS reads every sheet of an OpenDocument spreadsheet as a table of
+// "Column: value" records, using the text the cells display.
+func readODS(data []byte) ([]chunker.Table, error) {
+	files, err := zipFiles(data)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := openZipFile(files, "content.xml")
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var (
+		tables     []chunker.Table
+		name       string
+		rows       [][]string
+		row        []string
+		blanks     int
+		rowRepeat  int
+		cellRepeat int
+		cell       []string
+		para       strings.Builder
+		paraDepth  int
+		skip       int
+	)
+	decoder := xml.NewDecoder(rc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if skip > 0 || odfSkipped[t.Name.Local] {
+				skip++
+				continue
+			}
+			switch t.Name.Local {
+			case "table":
+				name = xmlAttr(t, "name")
+				rows = nil
+			case "table-row":
+				row, blanks = nil, 0
+				rowRepeat = repeat(xmlAttr(t, "number-rows-repeated"))
+			case "table-cell", "covered-table-cell":
+				cell = nil
+				cellRepeat = repeat(xmlAttr(t, "number-columns-repeated"))
+			case "p":
+				paraDepth++
+				if paraDepth == 1 {
+					para.Reset()
+				}
+			case "s":
+				n, err := strconv.Atoi(xmlAttr(t, "c"))
+				if err != nil || n < 1 {
+					n = 1
+				}
+				para.WriteString(strings.Repeat(" ", n))
+			case "tab":
+				para.WriteString("\t")
+			case "line-break":
+				para.WriteString("\n")
+			}
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			switch t.Name.Local {
+			case "p":
+				paraDepth--
+				if paraDepth == 0 {
+					cell = append(cell, para.String())
+				}
+			case "table-cell", "covered-table-cell":
+				value := strings.TrimSpace(strings.Join(cell, "\n"))
+				if value == "" {
+					blanks += cellRepeat
+					continue
+				}
+				for ; blanks > 0; blanks-- {
+					row = append(row, "")
+				}
+				for i := 0; i < cellRepeat; i++ {
+					row = append(row, value)
+				}
+			case "table-row":
+				if len(row) > 0 {
+					for i := 0; i < rowRepeat; i++ {
+						rows = append(rows, row)
+					}
+				}
+			case "table":
+				tables = append(tables, chunker.Table{Name: name, Page: len(tables) + 1, Records: rowRecords(rows)})
+			}
+		case xml.CharData:
+			if skip == 0 && paraDepth > 0 {
+				para.Write(t)
+			}
+		}
+	}
+	return tables, nil
+}
+
+func repeat(value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 1
+	}
+	return min(n, odsMaxRepeat)
+}
+
+func xmlAttr(t xml.StartElement, name string) string {
+	for _, attr := range t.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,396 @@
+package doc
+
+import (
+	"ai-service/internal/service/document"
+	"ai-service/internal/util/chunker"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dslipak/pdf"
+)
+
+const pdfType = "application/pdf"
+
+// pdfLine is a line of text split where the gap between glyphs is wide
+// enough to separate columns.
+type pdfLine struct {
+	y, size  float64
+	segments []pdfSegment
+}
+
+type pdfSegment struct {
+	x0, x1 float64
+	text   string
+}
+
+// readPDF reads the pages in reading order with the document info as
+// metadata. A page that cannot be read is logged and skipped. A page with
+// pictures but no text is recognised with opts.OCR, or reported as
+// image-only without it.
+func readPDF(ctx context.Context, data []byte, opts Options) (result *Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("read pdf: %v", r)
+		}
+	}()
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	report := document.Report{Metadata: pdfMetadata(r), Pages: r.NumPage()}
+	raw := &pdfRaw{data: data, encrypted: !r.Trailer().Key("Encrypt").IsNull()}
+	var parts []chunker.Part
+	for i := 1; i <= report.Pages; i++ {
+		text, images, err := pdfPage(r, i)
+		if err != nil {
+			log.Printf("pdf: page %d: %v", i, err)
+			report.FailedPages = append(report.FailedPages, document.PageError{Page: i, Error: err.Error()})
+			continue
+		}
+		if strings.TrimSpace(text) == "" {
+			if len(images) == 0 {
+				continue
+			}
+			if opts.OCR == nil {
+				report.ImageOnlyPages = append(report.ImageOnlyPages, i)
+				continue
+			}
+			text, err = pdfOCR(ctx, opts.OCR, raw, images)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				log.Printf("pdf: page %d: ocr: %v", i, err)
+				report.FailedPages = append(report.FailedPages, document.PageError{Page: i, Error: "ocr: " + err.Error()})
+				continue
+			}
+			if strings.TrimSpace(text) == "" {
+				report.ImageOnlyPages = append(report.ImageOnlyPages, i)
+				continue
+			}
+			report.OCRPages = append(report.OCRPages, i)
+		}
+		parts = append(parts, chunker.Part{Text: text, Page: i})
+	}
+	return &Result{Chunks: chunker.Split(parts, opts.Chunking), Report: report}, nil
+}
+
+// pdfPage returns the text of page num in reading order and the images the
+// page draws. The reader panics on malformed content, which is returned as
+// an error.
+func pdfPage(r *pdf.Reader, num int) (text string, images []pdf.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	page := r.Page(num)
+	if page.V.IsNull() {
+		return "", nil, fmt.Errorf("page not found")
+	}
+	lines := pdfLines(page.Content().Text)
+	return pdfText(lines, pdfWidth(page, lines)), pdfImages(page.Resources(), 0), nil
+}
+
+// pdfLines groups glyphs into lines from the top of the page down. Inside
+// a line a gap wider than one and a half characters starts a new segment;
+// a smaller one that is still visible becomes a space. Glyphs drawn twice
+// to fake bold are dropped.
+func pdfLines(glyphs []pdf.Text) []pdfLine {
+	sort.SliceStable(glyphs, func(i, j int) bool {
+		if glyphs[i].Y != glyphs[j].Y {
+			return glyphs[i].Y > glyphs[j].Y
+		}
+		return glyphs[i].X < glyphs[j].X
+	})
+
+	var groups [][]pdf.Text
+	for _, g := range glyphs {
+		if n := len(groups); n > 0 {
+			first := groups[n-1][0]
+			if math.Abs(first.Y-g.Y) <= math.Max(first.FontSize, g.FontSize)*0.5 {
+				groups[n-1] = append(groups[n-1], g)
+				continue
+			}
+		}
+		groups = append(groups, []pdf.Text{g})
+	}
+
+	lines := make([]pdfLine, 0, len(groups))
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool { return group[i].X < group[j].X })
+		line := pdfLine{y: group[0].Y}
+		var (
+			b    strings.Builder
+			seg  pdfSegment
+			prev *pdf.Text
+		)
+		for i := range group {
+			g := &group[i]
+			size := math.Max(g.FontSize, 1)
+			line.size = math.Max(line.size, size)
+			if prev != nil {
+				if g.S == prev.S && math.Abs(g.X-prev.X) < size*0.1 {
+					continue
+				}
+				gap := g.X - seg.x1
+				switch {
+				case gap > size*1.5:
+					seg.text = strings.TrimSpace(b.String())
+					line.segments = append(line.segments, seg)
+					b.Reset()
+					seg = pdfSegment{x0: g.X}
+				case gap > size*0.15 && g.S != " " && !strings.HasSuffix(b.String(), " "):
+					b.WriteString(" ")
+				}
+			} else {
+				seg.x0 = g.X
+			}
+			b.WriteString(g.S)
+			width := g.W
+			if width <= 0 {
+				width = size * 0.5 * float64(len([]rune(g.S)))
+			}
+			seg.x1 = math.Max(seg.x1, g.X+width)
+			prev = g
+		}
+		seg.text = strings.TrimSpace(b.String())
+		line.segments = append(line.segments, seg)
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func pdfWidth(page pdf.Page, lines []pdfLine) float64 {
+	box := page.V.Key("MediaBox")
+	// Pages inherit the box from the page tree.
+	for parent := page.V.Key("Parent"); box.IsNull() && !parent.IsNull(); parent = parent.Key("Parent") {
+		box = parent.Key("MediaBox")
+	}
+	if box.Len() == 4 {
+		if w := box.Index(2).Float64() - box.Index(0).Float64(); w > 0 {
+			return w
+		}
+	}
+	width := 0.0
+	for _, line := range lines {
+		for _, seg := range line.segments {
+			width = math.Max(width, seg.x1)
+		}
+	}
+	return width
+}
+
+// pdfGutter looks for the space between two columns of text: a vertical
+// strip in the middle of the page that almost no segment crosses, with
+// wide segments on both sides. Tables have narrow cells and stay in rows.
+func pdfGutter(lines []pdfLine, width float64) (float64, bool) {
+	if width <= 0 {
+		return 0, false
+	}
+	const bin = 2.0
+	coverage := make([]int, int(width/bin)+1)
+	segments := 0
+	for _, line := range lines {
+		for _, seg := range line.segments {
+			segments++
+			for x := max(int(seg.x0/bin), 0); x <= int(seg.x1/bin) && x < len(coverage); x++ {
+				coverage[x]++
+			}
+		}
+	}
+
+	limit := segments / 20
+	bestStart, bestLen, start := 0, 0, -1
+	for x := int(width * 0.3 / bin); x <= int(width*0.7/bin) && x < len(coverage); x++ {
+		if coverage[x] <= limit {
+			if start < 0 {
+				start = x
+			}
+			if x-start+1 > bestLen {
+				bestStart, bestLen = start, x-start+1
+			}
+		} else {
+			start = -1
+		}
+	}
+	if float64(bestLen)*bin < 6 {
+		return 0, false
+	}
+	gutter := (float64(bestStart) + float64(bestLen)/2) * bin
+
+	var left, right []float64
+	for _, line := range lines {
+		for _, seg := range line.segments {
+			switch {
+			case seg.x1 <= gutter:
+				left = append(left, seg.x1-seg.x0)
+			case seg.x0 >= gutter:
+				right = append(right, seg.x1-seg.x0)
+			}
+		}
+	}
+	if len(left) < 3 || len(right) < 3 || mean(left) < width*0.25 || mean(right) < width*0.25 {
+		return 0, false
+	}
+	return gutter, true
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// pdfText writes the lines in reading order. With two columns, the left
+// column is read before the right one between lines that cross the
+// gutter, such as titles. A gap between lines wider than usual starts a
+// paragraph.
+func pdfText(lines []pdfLine, width float64) string {
+	gutter, columns := pdfGutter(lines, width)
+
+	var (
+		out         strings.Builder
+		left, right []pdfLine
+	)
+	write := func(column []pdfLine) {
+		for i, line := range column {
+			text := joinSegments(line.segments)
+			if text == "" {
+				continue
+			}
+			if out.Len() > 0 {
+				if i == 0 || column[i-1].y-line.y > line.size*1.8 {
+					out.WriteString("\n\n")
+				} else {
+					out.WriteString("\n")
+				}
+			}
+			out.WriteString(text)
+		}
+	}
+	flush := func() {
+		write(left)
+		write(right)
+		left, right = nil, nil
+	}
+
+	if !columns {
+		write(lines)
+		return out.String()
+	}
+	for _, line := range lines {
+		var l, r pdfLine
+		crosses := false
+		for _, seg := range line.segments {
+			switch {
+			case seg.x1 <= gutter:
+				l.segments = append(l.segments, seg)
+			case seg.x0 >= gutter:
+				r.segments = append(r.segments, seg)
+			default:
+				crosses = true
+			}
+		}
+		if crosses {
+			flush()
+			write([]pdfLine{line})
+			continue
+		}
+		if len(l.segments) > 0 {
+			l.y, l.size = line.y, line.size
+			left = append(left, l)
+		}
+		if len(r.segments) > 0 {
+			r.y, r.size = line.y, line.size
+			right = append(right, r)
+		}
+	}
+	flush()
+	return out.String()
+}
+
+func joinSegments(segments []pdfSegment) string {
+	texts := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		if seg.text != "" {
+			texts = append(texts, seg.text)
+		}
+	}
+	return strings.Join(texts, " ")
+}
+
+// pdfImages returns the images in resources and in the forms they use.
+func pdfImages(resources pdf.Value, depth int) []pdf.Value {
+	var images []pdf.Value
+	objects := resources.Key("XObject")
+	for _, name := range objects.Keys() {
+		object := objects.Key(name)
+		switch object.Key("Subtype").Name() {
+		case "Image":
+			images = append(images, object)
+		case "Form":
+			if depth < 3 {
+				images = append(images, pdfImages(object.Key("Resources"), depth+1)...)
+			}
+		}
+	}
+	return images
+}
+
+func pdfMetadata(r *pdf.Reader) document.Metadata {
+	info := r.Trailer().Key("Info")
+	m := document.Metadata{
+		Title:  strings.TrimSpace(info.Key("Title").Text()),
+		Author: strings.TrimSpace(info.Key("Author").Text()),
+	}
+	if created, ok := parsePDFDate(info.Key("CreationDate").Text()); ok {
+		m.Created = &created
+	}
+	return m
+}
+
+// parsePDFDate parses a PDF date such as "D:20240131120000+03'00'". Every
+// part after the year is optional.
+func parsePDFDate(s string) (time.Time, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "D:")
+	digits := 0
+	for digits < len(s) && digits < 14 && s[digits] >= '0' && s[digits] <= '9' {
+		digits++
+	}
+	if digits < 4 {
+		return time.Time{}, false
+	}
+	field := func(from, to, def int) int {
+		if to > digits {
+			return def
+		}
+		v, _ := strconv.Atoi(s[from:to])
+		return v
+	}
+	loc := time.UTC
+	if zone := s[digits:]; len(zone) >= 3 && (zone[0] == '+' || zone[0] == '-') {
+		hours, _ := strconv.Atoi(zone[1:3])
+		minutes := 0
+		if rest := strings.TrimLeft(zone[3:], "'"); len(rest) >= 2 {
+			minutes, _ = strconv.Atoi(rest[:2])
+		}
+		offset := hours*3600 + minutes*60
+		if zone[0] == '-' {
+			offset = -offset
+		}
+		loc = time.FixedZone("", offset)
+	}
+	return time.Date(field(0, 4, 0), time.Month(field(4, 6, 1)), field(6, 8, 1),
+		field(8, 10, 0), field(10, 12, 0), field(12, 14, 0), 0, loc), true
+}
@@ -0,0 +1,146 @@
+package doc
+
+import (
+	"ai-service/internal/util/config"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildPDF writes a PDF of one page per content stream, drawn in
+// Helvetica, with info as the document info dictionary. Every glyph is
+// half a point size wide.
+func buildPDF(info string, pages ...string) []byte {
+	n := len(pages)
+	widths := strings.TrimSpace(strings.Repeat("500 ", 95))
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // the page tree, filled in below
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding /FirstChar 32 /LastChar 126 /Widths [" + widths + "] >>",
+		"<< " + info + " >>",
+	}
+	var kids []string
+	for _, content := range pages {
+		page := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", page+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), n)
+	return pdfFile(objects)
+}
+
+// pdfFile numbers objects from 1 and writes them with a cross-reference
+// table. Object 1 is the catalog and object 4 the document info.
+func pdfFile(objects []string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// pdfLinesAt draws each line at its own height, 20 points apart.
+func pdfLinesAt(x int, lines ...string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		fmt.Fprintf(&b, "BT /F1 12 Tf %d %d Td (%s) Tj ET\n", x, 700-20*i, line)
+	}
+	return b.String()
+}
+
+func TestReadPDF(t *testing.T) {
+	data := buildPDF("/Title (Report) /Author (Ivanov) /CreationDate (D:20240131120000+03'00')",
+		pdfLinesAt(72, "First line.", "Second line."),
+		"",
+		pdfLinesAt(72, "Third page."))
+	result, err := Read(context.Background(), data, "report.pdf", Options{Chunking: config.Chunking{Size: 30, Unit: "char"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Chunks) != 2 {
+		t.Fatalf("got %d chunks, want one per page with text: %q", len(result.Chunks), result.Chunks)
+	}
+	first, last := result.Chunks[0], result.Chunks[1]
+	if first.Page != 1 || first.Text != "First line.\nSecond line." {
+		t.Errorf("first chunk: page %d, text %q", first.Page, first.Text)
+	}
+	if last.Page != 3 || last.Text != "Third page." {
+		t.Errorf("last chunk: page %d, text %q", last.Page, last.Text)
+	}
+
+	report := result.Report
+	if report.Pages != 3 || len(report.FailedPages) != 0 || len(report.ImageOnlyPages) != 0 {
+		t.Errorf("report: %+v", report)
+	}
+	m := report.Metadata
+	if m.Title != "Report" || m.Author != "Ivanov" || m.Created == nil {
+		t.Fatalf("metadata: %+v", m)
+	}
+	if want := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC); !m.Created.Equal(want) {
+		t.Errorf("created %v, want %v", m.Created, want)
+	}
+}
+
+func TestReadPDFColumns(t *testing.T) {
+	content := pdfLinesAt(40, "Left column, first line of text.", "Left column, second line of text.", "Left column, third line of text.") +
+		pdfLinesAt(320, "Right column, first line of text.", "Right column, second line of text.", "Right column, third line.")
+	result, err := readPDF(context.Background(), buildPDF("", content), Options{Chunking: config.Chunking{Size: 1000, Unit: "char"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Chunks) != 1 {
+		t.Fatalf("got %d chunks, want 1", len(result.Chunks))
+	}
+	want := "Left column, first line of text.\nLeft column, second line of text.\nLeft column, third line of text.\n\n" +
+		"Right column, first line of text.\nRight column, second line of text.\nRight column, third line."
+	if got := result.Chunks[0].Text; got != want {
+		t.Errorf("text %q, want the left column first: %q", got, want)
+	}
+}
+
+func TestReadPDFWithoutText(t *testing.T) {
+	_, err := Read(context.Background(), buildPDF("", "", "0 0 m 100 100 l S"), "", Options{Chunking: config.Chunking{Size: 1000, Unit: "char"}})
+	if !errors.Is(err, ErrNoText) {
+		t.Fatalf("got %v, want ErrNoText", err)
+	}
+	if _, err := readPDF(context.Background(), []byte("%PDF-1.4\nbroken"), Options{}); err == nil {
+		t.Error("broken PDF read without an error")
+	}
+}
+
+func TestParsePDFDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+		ok   bool
+	}{
+		{"D:20240131120000Z", time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC), true},
+		{"D:20240131120000+03'00'", time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC), true},
+		{"D:20240131120000-05'30", time.Date(2024, 1, 31, 17, 30, 0, 0, time.UTC), true},
+		{"D:2024", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"202403", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"D:24", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parsePDFDate(tt.in)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("parsePDFDate(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
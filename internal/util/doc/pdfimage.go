@@ -0,0 +1,271 @@
+package doc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dslipak/pdf"
+	"golang.org/x/image/ccitt"
+)
+
+// pdfMaxPixels bounds the images decoded for OCR.
+const pdfMaxPixels = 100 << 20
+
+var (
+	pdfWidthKey  = regexp.MustCompile(`/Width\s+(\d+)`)
+	pdfHeightKey = regexp.MustCompile(`/Height\s+(\d+)`)
+)
+
+// pdfRaw finds the encoded bytes of image streams in the file. The reader
+// only decodes Flate, so JPEG and fax scans are taken as they are stored.
+// It does not tell where objects are, so a stream is matched by its
+// dimensions and by its length ending at "endstream".
+type pdfRaw struct {
+	data      []byte
+	encrypted bool
+	streams   []pdfStream
+	indexed   bool
+}
+
+type pdfStream struct {
+	width, height int
+	start         int
+}
+
+func (p *pdfRaw) index() {
+	p.indexed = true
+	for pos := 0; ; {
+		i := bytes.Index(p.data[pos:], []byte("stream"))
+		if i < 0 {
+			return
+		}
+		i += pos
+		pos = i + len("stream")
+		if i > 0 && p.data[i-1] == 'd' {
+			continue
+		}
+		start := pos
+		switch {
+		case bytes.HasPrefix(p.data[start:], []byte("\r\n")):
+			start += 2
+		case bytes.HasPrefix(p.data[start:], []byte("\n")), bytes.HasPrefix(p.data[start:], []byte("\r")):
+			start++
+		default:
+			continue
+		}
+		obj := bytes.LastIndex(p.data[max(i-4096, 0):i], []byte("obj"))
+		if obj < 0 {
+			continue
+		}
+		dict := p.data[max(i-4096, 0)+obj : i]
+		if !bytes.Contains(dict, []byte("/Image")) {
+			continue
+		}
+		w, h := pdfWidthKey.FindSubmatch(dict), pdfHeightKey.FindSubmatch(dict)
+		if w == nil || h == nil {
+			continue
+		}
+		width, _ := strconv.Atoi(string(w[1]))
+		height, _ := strconv.Atoi(string(h[1]))
+		p.streams = append(p.streams, pdfStream{width: width, height: height, start: start})
+	}
+}
+
+// stream returns the stored bytes of image v.
+func (p *pdfRaw) stream(v pdf.Value, width, height int) ([]byte, error) {
+	if p.encrypted {
+		return nil, errors.New("encrypted image")
+	}
+	if !p.indexed {
+		p.index()
+	}
+	length := int(v.Key("Length").Int64())
+	for _, s := range p.streams {
+		if s.width != width || s.height != height || s.start+length > len(p.data) {
+			continue
+		}
+		rest := bytes.TrimLeft(p.data[s.start+length:], "\r\n \t")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			return p.data[s.start : s.start+length], nil
+		}
+	}
+	return nil, errors.New("image stream not found")
+}
+
+// pdfOCR recognises the pictures of a page in the order the page lists
+// them. Pictures that fail are skipped as long as another one is read.
+func pdfOCR(ctx context.Context, ocr OCR, raw *pdfRaw, images []pdf.Value) (string, error) {
+	var (
+		texts    []string
+		firstErr error
+	)
+	for _, v := range images {
+		if v.Key("Width").Int64() < ocrMinSide || v.Key("Height").Int64() < ocrMinSide {
+			continue
+		}
+		data, mediaType, err := raw.image(v)
+		if err == nil {
+			var text string
+			text, err = ocr.Recognize(ctx, data, mediaType)
+			if text = strings.TrimSpace(text); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if len(texts) == 0 {
+		return "", firstErr
+	}
+	return strings.Join(texts, "\n\n"), nil
+}
+
+// image returns image v as JPEG or PNG. JPEG is passed through, fax and
+// Flate-compressed gray, RGB and CMYK samples are converted to PNG.
+func (p *pdfRaw) image(v pdf.Value) (data []byte, mediaType string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	width, height := int(v.Key("Width").Int64()), int(v.Key("Height").Int64())
+	if width <= 0 || height <= 0 || width*height > pdfMaxPixels {
+		return nil, "", fmt.Errorf("image size %dx%d", width, height)
+	}
+
+	filter := v.Key("Filter")
+	params := v.Key("DecodeParms")
+	if filter.Kind() == pdf.Array {
+		if filter.Len() != 1 {
+			return nil, "", fmt.Errorf("image filters %v", filter)
+		}
+		filter, params = filter.Index(0), params.Index(0)
+	}
+
+	var img image.Image
+	switch filter.Name() {
+	case "DCTDecode":
+		data, err := p.stream(v, width, height)
+		return data, jpegType, err
+	case "CCITTFaxDecode":
+		data, err := p.stream(v, width, height)
+		if err != nil {
+			return nil, "", err
+		}
+		img, err = pdfFax(data, params, width, height)
+		if err != nil {
+			return nil, "", err
+		}
+	case "", "FlateDecode":
+		img, err = pdfSamples(v, width, height)
+		if err != nil {
+			return nil, "", err
+		}
+	default:
+		return nil, "", fmt.Errorf("image filter %s", filter.Name())
+	}
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, "", err
+	}
+	return b.Bytes(), pngType, nil
+}
+
+func pdfFax(data []byte, params pdf.Value, width, height int) (image.Image, error) {
+	sf := ccitt.Group3
+	switch k := params.Key("K").Int64(); {
+	case k < 0:
+		sf = ccitt.Group4
+	case k > 0:
+		return nil, errors.New("mixed fax encoding")
+	}
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	opts := &ccitt.Options{
+		Align:  params.Key("EncodedByteAlign").Bool(),
+		Invert: params.Key("BlackIs1").Bool(),
+	}
+	if err := ccitt.DecodeIntoGray(img, bytes.NewReader(data), ccitt.MSB, sf, opts); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// pdfSamples decodes raw samples of 8 bits per component, or of one bit
+// for gray and stencil masks.
+func pdfSamples(v pdf.Value, width, height int) (image.Image, error) {
+	components := 1
+	if !v.Key("ImageMask").Bool() {
+		space := v.Key("ColorSpace")
+		if space.Kind() == pdf.Array && space.Index(0).Name() == "ICCBased" {
+			components = int(space.Index(1).Key("N").Int64())
+		} else {
+			switch space.Name() {
+			case "DeviceGray", "CalGray":
+				components = 1
+			case "DeviceRGB", "CalRGB":
+				components = 3
+			case "DeviceCMYK":
+				components = 4
+			default:
+				return nil, fmt.Errorf("image color space %v", space)
+			}
+		}
+	}
+	bits := int(v.Key("BitsPerComponent").Int64())
+	if v.Key("ImageMask").Bool() {
+		bits = 1
+	}
+	if bits != 8 && !(bits == 1 && components == 1) {
+		return nil, fmt.Errorf("image with %d bits per component", bits)
+	}
+
+	stride := (width*components*bits + 7) / 8
+	samples := make([]byte, stride*height)
+	rc := v.Reader()
+	defer rc.Close()
+	if _, err := io.ReadFull(rc, samples); err != nil {
+		return nil, err
+	}
+	rect := image.Rect(0, 0, width, height)
+	switch {
+	case bits == 1:
+		invert := v.Key("Decode").Index(0).Float64() == 1
+		img := image.NewGray(rect)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				on := samples[y*stride+x/8]&(0x80>>(x%8)) != 0
+				if on != invert {
+					img.Pix[y*img.Stride+x] = 0xff
+				}
+			}
+		}
+		return img, nil
+	case components == 1:
+		return &image.Gray{Pix: samples, Stride: stride, Rect: rect}, nil
+	case components == 3:
+		img := image.NewRGBA(rect)
+		for i := 0; i < width*height; i++ {
+			copy(img.Pix[i*4:], samples[i*3:i*3+3])
+			img.Pix[i*4+3] = 0xff
+		}
+		return img, nil
+	case components == 4:
+		return &image.CMYK{Pix: samples, Stride: stride, Rect: rect}, nil
+	}
+	return nil, fmt.Errorf("image with %d color components", components)
+}
@@ -0,0 +1,161 @@
+package doc
+
+import (
+	"ai-service/internal/util/chunker"
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const pptxType = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+
+const notesSlideRel = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/notesSlide"
+
+// pptxSkipped placeholders repeat on every slide and say nothing.
+var pptxSkipped = map[string]bool{
+	"sldNum": true,
+	"dt":     true,
+	"ftr":    true,
+	"hdr":    true,
+	"sldImg": true,
+}
+
+// pptxShape is the text of one shape or table on a slide.
+type pptxShape struct {
+	placeholder string
+	paragraphs  []string
+}
+
+// readPPTX reads each slide as a part numbered like the slide: the title
+// becomes the heading, followed by the text of the other shapes and the
+// speaker notes.
+func readPPTX(data []byte) ([]chunker.Part, error) {
+	files, err := zipFiles(data)
+	if err != nil {
+		return nil, err
+	}
+	var presentation struct {
+		Slides []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sldIdLst>sldId"`
+	}
+	if err := readZipXML(files, "ppt/presentation.xml", &presentation); err != nil {
+		return nil, err
+	}
+	rels, err := readRelationships(files, "ppt/presentation.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []chunker.Part
+	for i, slide := range presentation.Slides {
+		name := rels[slide.ID].Target
+		shapes, err := readPPTXShapes(files, name)
+		if err != nil {
+			return nil, err
+		}
+		var title string
+		var body []string
+		for _, shape := range shapes {
+			text := strings.Join(shape.paragraphs, "\n")
+			switch {
+			case text == "" || pptxSkipped[shape.placeholder]:
+			case shape.placeholder == "title" || shape.placeholder == "ctrTitle":
+				title = strings.Join(shape.paragraphs, " ")
+			default:
+				body = append(body, text)
+			}
+		}
+		if title == "" {
+			title = "Слайд " + strconv.Itoa(i+1)
+		}
+
+		slideRels, err := readRelationships(files, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, rel := range slideRels {
+			if rel.Type != notesSlideRel {
+				continue
+			}
+			notes, err := readPPTXShapes(files, rel.Target)
+			if err != nil {
+				return nil, err
+			}
+			for _, shape := range notes {
+				if shape.placeholder == "body" && len(shape.paragraphs) > 0 {
+					body = append(body, "Заметки докладчика:\n"+strings.Join(shape.paragraphs, "\n"))
+				}
+			}
+		}
+
+		text := "# " + title
+		if len(body) > 0 {
+			text += "\n\n" + strings.Join(body, "\n\n")
+		}
+		parts = append(parts, chunker.Part{Text: text, Page: i + 1})
+	}
+	return parts, nil
+}
+
+// readPPTXShapes collects the paragraphs of every shape and table in a
+// slide or notes part, in document order. A placeholder without a type is
+// a body placeholder.
+func readPPTXShapes(files map[string]*zip.File, name string) ([]pptxShape, error) {
+	rc, err := openZipFile(files, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var (
+		shapes    []pptxShape
+		paragraph strings.Builder
+		inText    bool
+	)
+	decoder := xml.NewDecoder(rc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "sp", "graphicFrame":
+				shapes = append(shapes, pptxShape{})
+			case "ph":
+				if len(shapes) > 0 {
+					shapes[len(shapes)-1].placeholder = "body"
+					if typ := xmlAttr(t, "type"); typ != "" {
+						shapes[len(shapes)-1].placeholder = typ
+					}
+				}
+			case "t":
+				inText = true
+			case "br":
+				paragraph.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				if text := strings.TrimSpace(paragraph.String()); text != "" && len(shapes) > 0 {
+					shapes[len(shapes)-1].paragraphs = append(shapes[len(shapes)-1].paragraphs, text)
+				}
+				paragraph.Reset()
+			}
+		case xml.CharData:
+			if inText {
+				paragraph.Write(t)
+			}
+		}
+	}
+	return shapes, nil
+}
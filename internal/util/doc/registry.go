@@ -0,0 +1,183 @@
+package doc
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// Format is a document type that can be ingested.
+type Format struct {
+	Name       string
+	MediaType  string
+	Extensions []string
+	load       loader
+}
+
+// formats are all the supported document types.
+var formats = []Format{
+	{Name: "PDF", MediaType: pdfType, Extensions: []string{".pdf"}, load: readPDF},
+	{Name: "DOCX", MediaType: docxType, Extensions: []string{".docx"}, load: readDOCX},
+	{Name: "XLSX", MediaType: xlsxType, Extensions: []string{".xlsx"}, load: spreadsheet(readXLSX)},
+	{Name: "PPTX", MediaType: pptxType, Extensions: []string{".pptx"}, load: prose(readPPTX)},
+	{Name: "ODT", MediaType: odtType, Extensions: []string{".odt"}, load: prose(readODT)},
+	{Name: "ODS", MediaType: odsType, Extensions: []string{".ods"}, load: spreadsheet(readODS)},
+	{Name: "EPUB", MediaType: epubType, Extensions: []string{".epub"}, load: prose(readEPUB)},
+	{Name: "RTF", MediaType: rtfType, Extensions: []string{".rtf"}, load: prose(readRTF)},
+	{Name: "HTML", MediaType: "text/html", Extensions: []string{".html", ".htm", ".xhtml"}, load: prose(readHTML)},
+	{Name: "Markdown", MediaType: "text/markdown", Extensions: []string{".md", ".markdown"}, load: prose(readMarkdown)},
+	{Name: "CSV", MediaType: "text/csv", Extensions: []string{".csv", ".tsv"}, load: spreadsheet(readCSV)},
+	{Name: "TXT", MediaType: "text/plain", Extensions: []string{".txt", ".text", ".log"}, load: prose(readText)},
+	{Name: "PNG", MediaType: pngType, Extensions: []string{".png"}, load: readImage},
+	{Name: "JPEG", MediaType: jpegType, Extensions: []string{".jpg", ".jpeg"}, load: readImage},
+	{Name: "TIFF", MediaType: tiffType, Extensions: []string{".tif", ".tiff"}, load: readImage},
+}
+
+// containerTypes are the formats packed in a ZIP archive.
+var containerTypes = map[string]bool{
+	docxType: true,
+	xlsxType: true,
+	pptxType: true,
+	odtType:  true,
+	odsType:  true,
+	epubType: true,
+}
+
+// UnsupportedFormatError is returned for documents of a type no loader
+// reads.
+type UnsupportedFormatError struct {
+	MediaType string
+	Filename  string
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	name := e.MediaType
+	if ext := filepath.Ext(e.Filename); ext != "" {
+		name += " (" + ext + ")"
+	}
+	return fmt.Sprintf("неподдерживаемый формат файла %s, поддерживаются: %s", name, strings.Join(Accepted(), ", "))
+}
+
+// Accepted lists the names of the supported formats.
+func Accepted() []string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// Detect returns the format of data. The extension of filename wins when it
+// agrees with the kind of content sniffed: text, a ZIP container or the
+// format itself. Without an extension, or with one the content contradicts,
+// the content decides.
+func Detect(data []byte, filename string) (Format, error) {
+	sniffed := sniff(data)
+	if f, ok := byExtension(filename); ok && kind(f.MediaType) == kind(sniffed) {
+		return f, nil
+	}
+	mediaType := detectType(data)
+	for _, f := range formats {
+		if f.MediaType == mediaType {
+			return f, nil
+		}
+	}
+	return Format{}, &UnsupportedFormatError{MediaType: mediaType, Filename: filename}
+}
+
+func byExtension(filename string) (Format, bool) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == "" {
+		return Format{}, false
+	}
+	for _, f := range formats {
+		for _, e := range f.Extensions {
+			if e == ext {
+				return f, true
+			}
+		}
+	}
+	return Format{}, false
+}
+
+// detectType sniffs the media type of data. CSV, Markdown and RTF sniff
+// as plain text and are told apart by their structure; office documents and
+// e-books sniff as ZIP and are told apart by their entries.
+func detectType(data []byte) string {
+	mediaType := sniff(data)
+	switch {
+	case mediaType == "text/plain" && bytes.HasPrefix(data, []byte(`{\rtf`)):
+		return rtfType
+	case mediaType == "text/plain" && looksLikeCSV(data):
+		return "text/csv"
+	case mediaType == "text/plain" && looksLikeMarkdown(data):
+		return "text/markdown"
+	case mediaType == "application/zip":
+		return zipType(data)
+	}
+	return mediaType
+}
+
+// sniff returns the media type net/http detects for data, and TIFF, which
+// it does not know, by its byte order mark.
+func sniff(data []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	if mediaType == "application/octet-stream" && (bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*"))) {
+		return tiffType
+	}
+	return mediaType
+}
+
+// kind groups the media types that sniff alike: every text type, RTF
+// included, and every ZIP container.
+func kind(mediaType string) string {
+	switch {
+	case strings.HasPrefix(mediaType, "text/") || mediaType == rtfType:
+		return "text"
+	case mediaType == "application/zip" || containerTypes[mediaType]:
+		return "zip"
+	}
+	return mediaType
+}
+
+// zipType recognises ZIP containers by a part only their format has. ODF
+// and EPUB name their type in a "mimetype" entry.
+func zipType(data []byte) string {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "application/zip"
+	}
+	for _, f := range zr.File {
+		switch f.Name {
+		case "word/document.xml":
+			return docxType
+		case "xl/workbook.xml":
+			return xlsxType
+		case "ppt/presentation.xml":
+			return pptxType
+		case "mimetype":
+			rc, err := f.Open()
+			if err != nil {
+				continue
+			}
+			content, err := io.ReadAll(io.LimitReader(rc, 100))
+			rc.Close()
+			if err != nil {
+				continue
+			}
+			switch mimeType := strings.TrimSpace(string(content)); mimeType {
+			case odtType, odsType, epubType:
+				return mimeType
+			}
+		}
+	}
+	return "application/zip"
+}
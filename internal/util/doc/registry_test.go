@@ -0,0 +1,71 @@
+package doc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	docx := zipOf(t, map[string]string{"word/document.xml": docxHead + `</w:body></w:document>`})
+	xlsx := zipOf(t, map[string]string{"xl/workbook.xml": "<workbook/>"})
+	odt := zipOf(t, map[string]string{"mimetype": odtType, "content.xml": "<office:document-content/>"})
+	csv := []byte("name,price\nчай,100\nкофе,200\n")
+	markdown := []byte("# Заголовок\n\nТекст с *выделением*.\n\n- пункт\n- пункт\n")
+	tests := []struct {
+		name, filename string
+		data           []byte
+		want           string
+	}{
+		// The content decides without an extension.
+		{"pdf", "", buildPDF("", ""), "PDF"},
+		{"docx", "", docx, "DOCX"},
+		{"xlsx", "", xlsx, "XLSX"},
+		{"odt", "", odt, "ODT"},
+		{"rtf", "", []byte(`{\rtf1\ansi Hello}`), "RTF"},
+		{"html", "", []byte("<!DOCTYPE html><html><body>Hi</body></html>"), "HTML"},
+		{"csv", "", csv, "CSV"},
+		{"markdown", "", markdown, "Markdown"},
+		{"text", "", []byte("Просто текст."), "TXT"},
+		{"png", "", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "PNG"},
+		{"tiff", "", []byte("II*\x00\x08\x00\x00\x00"), "TIFF"},
+
+		// An extension that agrees with the kind of content wins.
+		{"csv-like text", "notes.txt", csv, "TXT"},
+		{"markdown-like text", "notes.txt", markdown, "TXT"},
+		{"text as markdown", "readme.md", []byte("Просто текст."), "Markdown"},
+		{"html as markdown", "page.md", []byte("<html><body>Hi</body></html>"), "Markdown"},
+		{"upper-case extension", "DATA.CSV", []byte("a;b\n1;2\n"), "CSV"},
+		{"zip named xlsx", "report.xlsx", docx, "XLSX"},
+		{"rtf extension on text", "letter.rtf", []byte(`{\rtf1 Hi}`), "RTF"},
+
+		// The content decides when the extension contradicts it.
+		{"docx named txt", "report.txt", docx, "DOCX"},
+		{"pdf named docx", "report.docx", buildPDF("", ""), "PDF"},
+		{"text named pdf", "report.pdf", []byte("Просто текст."), "TXT"},
+		{"unknown extension", "data.bin", csv, "CSV"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Detect(tt.data, tt.filename)
+			if err != nil {
+				t.Fatalf("Detect: %v", err)
+			}
+			if f.Name != tt.want {
+				t.Errorf("Detect(%q) = %s, want %s", tt.filename, f.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectUnsupported(t *testing.T) {
+	for _, data := range [][]byte{
+		zipOf(t, map[string]string{"a.txt": "a"}),
+		{0x00, 0x01, 0x02, 0x03},
+	} {
+		_, err := Detect(data, "file.exe")
+		var unsupported *UnsupportedFormatError
+		if !errors.As(err, &unsupported) {
+			t.Errorf("got %v, want UnsupportedFormatError", err)
+		}
+	}
+}
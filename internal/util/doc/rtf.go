@@ -0,0 +1,259 @@
+package doc
+
+import (
+	"ai-service/internal/util/chunker"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+const rtfType = "application/rtf"
+
+// rtfSkipped destinations hold tables, metadata and pictures, not text.
+var rtfSkipped = map[string]bool{
+	"colortbl":           true,
+	"stylesheet":         true,
+	"info":               true,
+	"pict":               true,
+	"object":             true,
+	"themedata":          true,
+	"colorschememapping": true,
+	"datastore":          true,
+	"latentstyles":       true,
+	"listtable":          true,
+	"listoverridetable":  true,
+	"rsidtbl":            true,
+	"generator":          true,
+	"xmlnstbl":           true,
+	"mmathPr":            true,
+	"fldinst":            true,
+	"revtbl":             true,
+	"filetbl":            true,
+	"header":             true,
+	"headerl":            true,
+	"headerr":            true,
+	"headerf":            true,
+	"footer":             true,
+	"footerl":            true,
+	"footerr":            true,
+	"footerf":            true,
+	"footnote":           true,
+	"annotation":         true,
+}
+
+var rtfSymbols = map[string]string{
+	"par":       "\n\n",
+	"sect":      "\n\n",
+	"line":      "\n",
+	"row":       "\n",
+	"tab":       "\t",
+	"cell":      " | ",
+	"emdash":    "—",
+	"endash":    "–",
+	"bullet":    "•",
+	"lquote":    "‘",
+	"rquote":    "’",
+	"ldblquote": "“",
+	"rdblquote": "”",
+	"emspace":   " ",
+	"enspace":   " ",
+}
+
+// rtfCodePages maps \ansicpg values to code pages.
+var rtfCodePages = map[int]*charmap.Charmap{
+	866:  charmap.CodePage866,
+	1250: charmap.Windows1250,
+	1251: charmap.Windows1251,
+	1252: charmap.Windows1252,
+	1253: charmap.Windows1253,
+	1254: charmap.Windows1254,
+	1255: charmap.Windows1255,
+	1256: charmap.Windows1256,
+	1257: charmap.Windows1257,
+	1258: charmap.Windows1258,
+}
+
+// rtfCharsets maps \fcharset values of fonts to code pages.
+var rtfCharsets = map[int]*charmap.Charmap{
+	161: charmap.Windows1253,
+	162: charmap.Windows1254,
+	163: charmap.Windows1258,
+	177: charmap.Windows1255,
+	178: charmap.Windows1256,
+	186: charmap.Windows1257,
+	204: charmap.Windows1251,
+	238: charmap.Windows1250,
+}
+
+type rtfState struct {
+	skip    bool
+	fonttbl bool
+	uc      int
+	page    *charmap.Charmap
+}
+
+// readRTF extracts the body text of an RTF document. Escaped bytes are
+// decoded with the code page of the current font, \page starts a new part
+// and table cells are separated by "|".
+func readRTF(data []byte) ([]chunker.Part, error) {
+	var (
+		parts    []chunker.Part
+		out      strings.Builder
+		raw      []byte
+		stack    []rtfState
+		state    = rtfState{uc: 1, page: charmap.Windows1252}
+		fonts    = map[int]*charmap.Charmap{}
+		font     int
+		pending  int
+		defaults = charmap.Windows1252
+	)
+	flush := func() {
+		if len(raw) > 0 {
+			out.WriteString(decodeWith(state.page, raw))
+			raw = raw[:0]
+		}
+	}
+	write := func(s string) {
+		flush()
+		if !state.skip && !state.fonttbl {
+			out.WriteString(s)
+		}
+	}
+	addByte := func(b byte) {
+		if pending > 0 {
+			pending--
+			return
+		}
+		if !state.skip && !state.fonttbl {
+			raw = append(raw, b)
+		}
+	}
+	newPart := func() {
+		flush()
+		parts = append(parts, chunker.Part{Text: tidyRTF(out.String()), Page: len(parts) + 1})
+		out.Reset()
+	}
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '{':
+			flush()
+			stack = append(stack, state)
+		case '}':
+			flush()
+			if len(stack) > 0 {
+				state = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case '\r', '\n':
+		case '\\':
+			if i+1 >= len(data) {
+				break
+			}
+			i++
+			c = data[i]
+			switch {
+			case c == '\'':
+				if i+2 < len(data) {
+					if b, err := strconv.ParseUint(string(data[i+1:i+3]), 16, 8); err == nil {
+						addByte(byte(b))
+					}
+					i += 2
+				}
+			case c == '*':
+				state.skip = true
+			case c == '\\' || c == '{' || c == '}':
+				if pending > 0 {
+					pending--
+				} else {
+					write(string(c))
+				}
+			case c == '~':
+				write(" ")
+			case c == '_':
+				write("-")
+			case c == '\r' || c == '\n':
+				write("\n\n")
+			case isASCIILetter(c):
+				start := i
+				for i < len(data) && isASCIILetter(data[i]) {
+					i++
+				}
+				word := string(data[start:i])
+				numStart := i
+				if i < len(data) && data[i] == '-' {
+					i++
+				}
+				for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+					i++
+				}
+				param, hasParam := 0, i > numStart
+				if hasParam {
+					param, _ = strconv.Atoi(string(data[numStart:i]))
+				}
+				if i >= len(data) || data[i] != ' ' {
+					i--
+				}
+
+				switch {
+				case word == "bin" && hasParam:
+					i += param
+				case word == "fonttbl":
+					state.fonttbl = true
+				case rtfSkipped[word]:
+					state.skip = true
+				case word == "ansicpg":
+					if page, ok := rtfCodePages[param]; ok {
+						defaults = page
+						state.page = page
+					}
+				case word == "f":
+					if state.fonttbl {
+						font = param
+					} else {
+						flush()
+						state.page = defaults
+						if page, ok := fonts[param]; ok {
+							state.page = page
+						}
+					}
+				case word == "fcharset" && state.fonttbl:
+					if page, ok := rtfCharsets[param]; ok {
+						fonts[font] = page
+					}
+				case word == "uc":
+					state.uc = param
+				case word == "u":
+					if param < 0 {
+						param += 65536
+					}
+					write(string(rune(param)))
+					pending = state.uc
+				case word == "page":
+					newPart()
+				case rtfSymbols[word] != "":
+					write(rtfSymbols[word])
+				}
+			}
+		default:
+			addByte(c)
+		}
+	}
+	newPart()
+	return parts, nil
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// tidyRTF drops the separator left after the last cell of each row.
+func tidyRTF(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(strings.TrimRight(line, " "), " |")
+	}
+	return strings.Join(lines, "\n")
+}
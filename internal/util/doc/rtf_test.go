@@ -0,0 +1,60 @@
+package doc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadRTF(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		parts []string
+	}{
+		{
+			name:  "plain",
+			in:    `{\rtf1\ansi{\fonttbl{\f0 Arial;}}\f0 Hello\par World}`,
+			parts: []string{"Hello\n\nWorld"},
+		},
+		{
+			name:  "cyrillic code page",
+			in:    `{\rtf1\ansi\ansicpg1251{\fonttbl{\f0\fcharset204 Arial;}}\f0 \'cf\'f0\'e8\'e2\'e5\'f2}`,
+			parts: []string{"Привет"},
+		},
+		{
+			name:  "unicode escapes",
+			in:    `{\rtf1\ansi\uc1 \u1055?\u1088?\u1080?}`,
+			parts: []string{"При"},
+		},
+		{
+			name:  "skipped destinations",
+			in:    `{\rtf1\ansi{\info{\title Секрет}}{\*\generator Word;}Body}`,
+			parts: []string{"Body"},
+		},
+		{
+			name:  "pages",
+			in:    `{\rtf1\ansi One\page Two}`,
+			parts: []string{"One", "Two"},
+		},
+		{
+			name:  "table",
+			in:    `{\rtf1\ansi\trowd A\cell B\cell\row}`,
+			parts: []string{"A | B"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := readRTF([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range parts {
+				got = append(got, strings.TrimSpace(p.Text))
+			}
+			if strings.Join(got, "\x00") != strings.Join(tt.parts, "\x00") {
+				t.Errorf("parts %q, want %q", got, tt.parts)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package doc
+
+import (
+	"ai-service/internal/util/chunker"
+	"bytes"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+// cyrillicPages are tried, in this order, for text that is not UTF-8.
+var cyrillicPages = []*charmap.Charmap{
+	charmap.Windows1251,
+	charmap.KOI8R,
+	charmap.CodePage866,
+}
+
+func readText(data []byte) ([]chunker.Part, error) {
+	return []chunker.Part{{Text: decodeText(data)}}, nil
+}
+
+// decodeText converts data to UTF-8 with LF line endings. A byte order mark
+// decides the encoding; valid UTF-8 is kept as is; anything else is read
+// with the Cyrillic code page that yields the most lowercase Cyrillic
+// letters, or as Windows-1252 when none yields any.
+func decodeText(data []byte) string {
+	var text string
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		text = string(data[3:])
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		text = decodeWith(xunicode.UTF16(xunicode.LittleEndian, xunicode.ExpectBOM), data)
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		text = decodeWith(xunicode.UTF16(xunicode.BigEndian, xunicode.ExpectBOM), data)
+	case utf8.Valid(data):
+		text = string(data)
+	default:
+		text = decodeWith(charmap.Windows1252, data)
+		best := 0
+		for _, page := range cyrillicPages {
+			decoded := decodeWith(page, data)
+			if score := lowercaseCyrillic(decoded); score > best {
+				text, best = decoded, score
+			}
+		}
+	}
+	return normalizeNewlines(text)
+}
+
+func decodeWith(e encoding.Encoding, data []byte) string {
+	decoded, err := e.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
+
+// lowercaseCyrillic counts lowercase Cyrillic letters. Real text is mostly
+// lowercase, while a wrong code page turns it into uppercase or symbols.
+func lowercaseCyrillic(text string) int {
+	n := 0
+	for _, r := range text {
+		if unicode.Is(unicode.Cyrillic, r) && unicode.IsLower(r) {
+			n++
+		}
+	}
+	return n
+}
+
+func normalizeNewlines(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
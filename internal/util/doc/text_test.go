@@ -0,0 +1,44 @@
+package doc
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+func encode(t *testing.T, e encoding.Encoding, s string) []byte {
+	t.Helper()
+	b, err := e.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDecodeText(t *testing.T) {
+	const text = "Съешь же ещё этих мягких французских булок, да выпей чаю."
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"utf-8", []byte(text), text},
+		{"utf-8 with bom", append([]byte{0xEF, 0xBB, 0xBF}, text...), text},
+		{"utf-16le", encode(t, xunicode.UTF16(xunicode.LittleEndian, xunicode.UseBOM), text), text},
+		{"utf-16be", encode(t, xunicode.UTF16(xunicode.BigEndian, xunicode.UseBOM), text), text},
+		{"windows-1251", encode(t, charmap.Windows1251, text), text},
+		{"koi8-r", encode(t, charmap.KOI8R, text), text},
+		{"cp866", encode(t, charmap.CodePage866, text), text},
+		{"windows-1252", encode(t, charmap.Windows1252, "Price: 5€ ™"), "Price: 5€ ™"},
+		{"line endings", []byte("a\r\nb\rc\n"), "a\nb\nc\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeText(tt.data); got != tt.want {
+				t.Errorf("decodeText = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
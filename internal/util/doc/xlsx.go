@@ -0,0 +1,199 @@
+package doc
+
+import (
+	"ai-service/internal/util/chunker"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const xlsxType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name  string `xml:"name,attr"`
+		State string `xml:"state,attr"`
+		ID    string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxText is a shared or inline string: plain, or split into runs.
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	s := t.T
+	for _, r := range t.Runs {
+		s += r.T
+	}
+	return s
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Style  int      `xml:"s,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+var (
+	// dateCode matches number formats that show a date or time once quoted
+	// text and colours are removed.
+	dateCode   = regexp.MustCompile(`[dmyhs]`)
+	formatText = regexp.MustCompile(`"[^"]*"|\[[^\]]*\]|\\.`)
+	// excelEpoch is day 0 of the 1900 date system, adjusted for the
+	// fictitious 29 February 1900.
+	excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+)
+
+// readXLSX reads every visible sheet of a workbook as a table of
+// "Column: value" records, with the sheet name as the section and the
+// sheet number as the page.
+func readXLSX(data []byte) ([]chunker.Table, error) {
+	files, err := zipFiles(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var workbook xlsxWorkbook
+	if err := readZipXML(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	rels, err := readRelationships(files, "xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	var shared []string
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst struct {
+			Items []xlsxText `xml:"si"`
+		}
+		if err := readZipXML(files, "xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+		for _, item := range sst.Items {
+			shared = append(shared, item.String())
+		}
+	}
+	var dates []bool
+	if _, ok := files["xl/styles.xml"]; ok {
+		var styles xlsxStyles
+		if err := readZipXML(files, "xl/styles.xml", &styles); err != nil {
+			return nil, err
+		}
+		dates = dateStyles(styles)
+	}
+
+	var tables []chunker.Table
+	for i, s := range workbook.Sheets {
+		if s.State == "hidden" || s.State == "veryHidden" {
+			continue
+		}
+		var sheet xlsxSheet
+		if err := readZipXML(files, rels[s.ID].Target, &sheet); err != nil {
+			return nil, err
+		}
+		var rows [][]string
+		for _, row := range sheet.Rows {
+			var values []string
+			for _, c := range row.Cells {
+				col := columnIndex(c.Ref)
+				if col < 0 {
+					col = len(values)
+				}
+				for len(values) <= col {
+					values = append(values, "")
+				}
+				values[col] = cellValue(c.Type, c.Value, c.Inline, c.Style, shared, dates)
+			}
+			rows = append(rows, values)
+		}
+		tables = append(tables, chunker.Table{Name: s.Name, Page: i + 1, Records: rowRecords(rows)})
+	}
+	return tables, nil
+}
+
+func cellValue(typ, value string, inline xlsxText, style int, shared []string, dates []bool) string {
+	switch typ {
+	case "s":
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 || i >= len(shared) {
+			return ""
+		}
+		return shared[i]
+	case "inlineStr":
+		return inline.String()
+	case "b":
+		if value == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	case "str", "e":
+		return value
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+	if style >= 0 && style < len(dates) && dates[style] {
+		t := excelEpoch.Add(time.Duration(f * 24 * float64(time.Hour))).Round(time.Second)
+		if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+			return t.Format("2006-01-02")
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// dateStyles tells for each cell style whether its number format is a date.
+func dateStyles(styles xlsxStyles) []bool {
+	custom := make(map[int]bool, len(styles.NumFmts))
+	for _, f := range styles.NumFmts {
+		custom[f.ID] = dateCode.MatchString(strings.ToLower(formatText.ReplaceAllString(f.Code, "")))
+	}
+	dates := make([]bool, len(styles.CellXfs))
+	for i, xf := range styles.CellXfs {
+		id := xf.NumFmtID
+		if isDate, ok := custom[id]; ok {
+			dates[i] = isDate
+		} else {
+			// Built-in date and time formats.
+			dates[i] = (id >= 14 && id <= 22) || (id >= 45 && id <= 47)
+		}
+	}
+	return dates
+}
+
+// columnIndex converts the letters of a cell reference such as "AB12" to a
+// zero-based column number.
+func columnIndex(ref string) int {
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		n = n*26 + int(r-'A'+1)
+	}
+	return n - 1
+}
@@ -0,0 +1,95 @@
+package doc
+
+import (
+	"reflect"
+	"testing"
+)
+
+const xlsxNS = `xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+
+func xlsxOf(t *testing.T) []byte {
+	t.Helper()
+	return zipOf(t, map[string]string{
+		"xl/workbook.xml": `<workbook ` + xlsxNS + `><sheets>` +
+			`<sheet name="Цены" sheetId="1" r:id="rId1"/>` +
+			`<sheet name="Скрытый" sheetId="2" state="hidden" r:id="rId2"/>` +
+			`<sheet name="Прочее" sheetId="3" r:id="rId3"/>` +
+			`</sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.xml"/>` +
+			`<Relationship Id="rId2" Target="worksheets/sheet2.xml"/>` +
+			`<Relationship Id="rId3" Target="/xl/worksheets/sheet3.xml"/>` +
+			`</Relationships>`,
+		"xl/sharedStrings.xml": `<sst ` + xlsxNS + `>` +
+			`<si><t>Товар</t></si><si><t>Цена</t></si><si><t>Дата</t></si>` +
+			`<si><r><t>Чай </t></r><r><t>зелёный</t></r></si>` +
+			`</sst>`,
+		"xl/styles.xml": `<styleSheet ` + xlsxNS + `>` +
+			`<numFmts><numFmt numFmtId="164" formatCode="dd/mm/yyyy hh:mm"/><numFmt numFmtId="165" formatCode="0.00&quot; руб.&quot;"/></numFmts>` +
+			`<cellXfs><xf numFmtId="0"/><xf numFmtId="14"/><xf numFmtId="164"/><xf numFmtId="165"/></cellXfs>` +
+			`</styleSheet>`,
+		"xl/worksheets/sheet1.xml": `<worksheet ` + xlsxNS + `><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="s"><v>2</v></c></row>` +
+			`<row r="2"><c r="A2" t="s"><v>3</v></c><c r="B2" s="3"><v>120.5</v></c><c r="D2" s="1"><v>45292</v></c></row>` +
+			`<row r="3"><c r="A3" t="inlineStr"><is><t>Кофе</t></is></c><c r="C3" t="b"><v>1</v></c><c r="D3" s="2"><v>45292.5</v></c></row>` +
+			`</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet ` + xlsxNS + `><sheetData>` +
+			`<row r="1"><c r="A1" t="str"><v>секрет</v></c></row>` +
+			`</sheetData></worksheet>`,
+		"xl/worksheets/sheet3.xml": `<worksheet ` + xlsxNS + `><sheetData>` +
+			`<row r="1"><c r="A1" t="str"><v>одна ячейка</v></c></row>` +
+			`</sheetData></worksheet>`,
+	})
+}
+
+func TestReadXLSX(t *testing.T) {
+	tables, err := readXLSX(xlsxOf(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("got %d tables, want the 2 visible sheets: %+v", len(tables), tables)
+	}
+	prices := tables[0]
+	want := []string{
+		"Товар: Чай зелёный\nЦена: 120.5\nДата: 2024-01-01",
+		"Товар: Кофе\nСтолбец 3: TRUE\nДата: 2024-01-01 12:00:00",
+	}
+	if prices.Name != "Цены" || prices.Page != 1 || !reflect.DeepEqual(prices.Records, want) {
+		t.Errorf("first sheet %+v, want records %q", prices, want)
+	}
+	other := tables[1]
+	if other.Name != "Прочее" || other.Page != 3 || !reflect.DeepEqual(other.Records, []string{"Столбец 1: одна ячейка"}) {
+		t.Errorf("third sheet %+v", other)
+	}
+}
+
+func TestDateStyles(t *testing.T) {
+	var styles xlsxStyles
+	for _, f := range []struct {
+		id   int
+		code string
+	}{{164, "yyyy-mm-dd"}, {165, `"Итого: "0.00`}, {166, "[Red]0.00"}, {167, `0\h`}} {
+		styles.NumFmts = append(styles.NumFmts, struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		}{f.id, f.code})
+	}
+	for _, id := range []int{0, 14, 22, 45, 49, 164, 165, 166, 167} {
+		styles.CellXfs = append(styles.CellXfs, struct {
+			NumFmtID int `xml:"numFmtId,attr"`
+		}{id})
+	}
+	want := []bool{false, true, true, true, false, true, false, false, false}
+	if got := dateStyles(styles); !reflect.DeepEqual(got, want) {
+		t.Errorf("dateStyles() = %v, want %v", got, want)
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	for ref, want := range map[string]int{"A1": 0, "Z9": 25, "AA10": 26, "AB12": 27, "": -1, "12": -1} {
+		if got := columnIndex(ref); got != want {
+			t.Errorf("columnIndex(%q) = %d, want %d", ref, got, want)
+		}
+	}
+}
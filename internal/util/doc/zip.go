@@ -0,0 +1,77 @@
+package doc
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// zipFiles indexes the entries of a ZIP archive by name.
+func zipFiles(data []byte) (map[string]*zip.File, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	return files, nil
+}
+
+func openZipFile(files map[string]*zip.File, name string) (io.ReadCloser, error) {
+	f, ok := files[name]
+	if !ok {
+		return nil, fmt.Errorf("could not find '%s' in archive", name)
+	}
+	return f.Open()
+}
+
+func readZipXML(files map[string]*zip.File, name string, v any) error {
+	rc, err := openZipFile(files, name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// relationship is an OOXML link from one part to another, with Target
+// resolved to an archive path.
+type relationship struct {
+	Type   string
+	Target string
+}
+
+// readRelationships reads the relationships of the part at name, keyed by
+// ID. A part without any has none.
+func readRelationships(files map[string]*zip.File, name string) (map[string]relationship, error) {
+	dir, file := path.Split(name)
+	relsName := path.Join(dir, "_rels", file+".rels")
+	if _, ok := files[relsName]; !ok {
+		return nil, nil
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Type   string `xml:"Type,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := readZipXML(files, relsName, &rels); err != nil {
+		return nil, err
+	}
+	out := make(map[string]relationship, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		target := path.Join(dir, rel.Target)
+		if strings.HasPrefix(rel.Target, "/") {
+			target = strings.TrimPrefix(rel.Target, "/")
+		}
+		out[rel.ID] = relationship{Type: rel.Type, Target: target}
+	}
+	return out, nil
+}
@@ -36,3 +36,15 @@ func NewInternalErrorRsp(msg string) *echo.HTTPError {
 func NewBadRequestErrorRsp(msg string) *echo.HTTPError {
 	return NewCustomErrorResponse(http.StatusBadRequest, msg)
 }
+
+func NewNotFoundErrorRsp(msg string) *echo.HTTPError {
+	return NewCustomErrorResponse(http.StatusNotFound, msg)
+}
+
+func NewConflictErrorRsp(msg string) *echo.HTTPError {
+	return NewCustomErrorResponse(http.StatusConflict, msg)
+}
+
+func NewUnprocessableErrorRsp(msg string) *echo.HTTPError {
+	return NewCustomErrorResponse(http.StatusUnprocessableEntity, msg)
+}
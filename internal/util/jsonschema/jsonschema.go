@@ -0,0 +1,410 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema is the subset of JSON Schema that Ollama structured outputs use:
+// type, properties, required, additionalProperties, items, enum, const,
+// numeric and length bounds, pattern, and anyOf/oneOf/allOf. Parse rejects
+// other keywords rather than leave them unenforced.
+type Schema struct {
+	Type                 typeList           `json:"type"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *json.RawMessage   `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	Const                constant           `json:"const"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+	Pattern              string             `json:"pattern"`
+	AnyOf                []*Schema          `json:"anyOf"`
+	OneOf                []*Schema          `json:"oneOf"`
+	AllOf                []*Schema          `json:"allOf"`
+
+	pattern *regexp.Regexp
+	extra   *Schema
+	closed  bool
+}
+
+// constant tells "const": null from a missing const.
+type constant struct {
+	set   bool
+	value any
+}
+
+func (c *constant) UnmarshalJSON(data []byte) error {
+	c.set = true
+	return decode(data, &c.value)
+}
+
+// keywords are the keywords Parse accepts. Annotations are allowed and
+// ignored; any other keyword would be silently unenforced, so it is
+// rejected.
+var keywords = map[string]bool{
+	"type": true, "properties": true, "required": true, "additionalProperties": true,
+	"items": true, "enum": true, "const": true, "minimum": true, "maximum": true,
+	"minLength": true, "maxLength": true, "minItems": true, "maxItems": true,
+	"pattern": true, "anyOf": true, "oneOf": true, "allOf": true,
+
+	"$schema": true, "$id": true, "$comment": true, "title": true,
+	"description": true, "default": true, "examples": true,
+}
+
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = typeList{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = many
+	return nil
+}
+
+// Parse decodes and checks a schema document.
+func Parse(raw []byte) (*Schema, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		return nil, fmt.Errorf("schema must be a JSON object")
+	}
+	if err := checkKeywords("#", raw); err != nil {
+		return nil, err
+	}
+	s := new(Schema)
+	if err := decode(raw, s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := s.compile("#"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// decode unmarshals with numbers kept as json.Number, so enum and const
+// values compare exactly.
+func decode(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// checkKeywords walks the schema document and fails on keywords the
+// validator does not implement.
+func checkKeywords(path string, raw json.RawMessage) error {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		// Booleans and malformed schemas are left to the decoder.
+		return nil
+	}
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !keywords[name] {
+			return fmt.Errorf("%s: unsupported keyword %q", path, name)
+		}
+		value := obj[name]
+		switch name {
+		case "properties":
+			var props map[string]json.RawMessage
+			if err := json.Unmarshal(value, &props); err != nil {
+				continue
+			}
+			for prop, sub := range props {
+				if err := checkKeywords(path+"/properties/"+prop, sub); err != nil {
+					return err
+				}
+			}
+		case "items", "additionalProperties":
+			if err := checkKeywords(path+"/"+name, value); err != nil {
+				return err
+			}
+		case "anyOf", "oneOf", "allOf":
+			var list []json.RawMessage
+			if err := json.Unmarshal(value, &list); err != nil {
+				continue
+			}
+			for i, sub := range list {
+				if err := checkKeywords(fmt.Sprintf("%s/%s/%d", path, name, i), sub); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) compile(path string) error {
+	for _, t := range s.Type {
+		switch t {
+		case "object", "array", "string", "number", "integer", "boolean", "null":
+		default:
+			return fmt.Errorf("%s: unknown type %q", path, t)
+		}
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern: %w", path, err)
+		}
+		s.pattern = re
+	}
+	if s.AdditionalProperties != nil {
+		var allowed bool
+		if err := json.Unmarshal(*s.AdditionalProperties, &allowed); err == nil {
+			s.closed = !allowed
+		} else {
+			s.extra = new(Schema)
+			if err := decode(*s.AdditionalProperties, s.extra); err != nil {
+				return fmt.Errorf("%s/additionalProperties: %w", path, err)
+			}
+			if err := s.extra.compile(path + "/additionalProperties"); err != nil {
+				return err
+			}
+		}
+	}
+	for name, p := range s.Properties {
+		if err := p.compile(path + "/properties/" + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.compile(path + "/items"); err != nil {
+			return err
+		}
+	}
+	for kind, list := range map[string][]*Schema{"anyOf": s.AnyOf, "oneOf": s.OneOf, "allOf": s.AllOf} {
+		for i, sub := range list {
+			if err := sub.compile(fmt.Sprintf("%s/%s/%d", path, kind, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks a JSON document against the schema and returns the
+// decoded value.
+func (s *Schema) Validate(doc []byte) (any, error) {
+	var v any
+	if err := decode(doc, &v); err != nil {
+		return nil, fmt.Errorf("output is not valid JSON: %w", err)
+	}
+	if err := s.validate("$", v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (s *Schema) validate(path string, v any) error {
+	if len(s.Type) > 0 && !s.matchesType(v) {
+		return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(s.Type, " or "), typeOf(v))
+	}
+	if len(s.Enum) > 0 && !contains(s.Enum, v) {
+		return fmt.Errorf("%s: value is not one of the allowed values", path)
+	}
+	if s.Const.set && !equal(s.Const.value, v) {
+		return fmt.Errorf("%s: value must be %v", path, s.Const.value)
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		names := make([]string, 0, len(val))
+		for name := range val {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sub, ok := s.Properties[name]
+			switch {
+			case ok:
+			case s.extra != nil:
+				sub = s.extra
+			case s.closed:
+				return fmt.Errorf("%s: unexpected property %q", path, name)
+			default:
+				continue
+			}
+			if err := sub.validate(path+"."+name, val[name]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			return fmt.Errorf("%s: expected at least %d items, got %d", path, *s.MinItems, len(val))
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			return fmt.Errorf("%s: expected at most %d items, got %d", path, *s.MaxItems, len(val))
+		}
+		if s.Items != nil {
+			for i, item := range val {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		n := len([]rune(val))
+		if s.MinLength != nil && n < *s.MinLength {
+			return fmt.Errorf("%s: expected at least %d characters", path, *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			return fmt.Errorf("%s: expected at most %d characters", path, *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(val) {
+			return fmt.Errorf("%s: does not match pattern %q", path, s.Pattern)
+		}
+	case json.Number:
+		f, _ := val.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			return fmt.Errorf("%s: must be >= %v", path, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return fmt.Errorf("%s: must be <= %v", path, *s.Maximum)
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		if err := sub.validate(path, v); err != nil {
+			return err
+		}
+	}
+	if len(s.AnyOf) > 0 {
+		var firstErr error
+		matched := false
+		for _, sub := range s.AnyOf {
+			if err := sub.validate(path, v); err == nil {
+				matched = true
+				break
+			} else if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: does not match any allowed schema (%v)", path, firstErr)
+		}
+	}
+	if len(s.OneOf) > 0 {
+		matches := 0
+		for _, sub := range s.OneOf {
+			if sub.validate(path, v) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fmt.Errorf("%s: must match exactly one schema, matched %d", path, matches)
+		}
+	}
+	return nil
+}
+
+func (s *Schema) matchesType(v any) bool {
+	actual := typeOf(v)
+	for _, t := range s.Type {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case json.Number:
+		// A number with a zero fractional part, such as 1.0, is an integer.
+		if r, ok := number(val); ok && r.IsInt() {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func contains(values []any, v any) bool {
+	for _, candidate := range values {
+		if equal(candidate, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal compares JSON values; numbers are equal by value, so 1 equals 1.0.
+func equal(a, b any) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x.Cmp(y) == 0
+	}
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, va := range a {
+			vb, ok := b[k]
+			if !ok || !equal(va, vb) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case nil, bool, string:
+		return a == b
+	}
+	return false
+}
+
+// number reads a JSON number. A big.Float keeps integers and exponents of
+// any size without the cost of an exact rational.
+func number(v any) (*big.Float, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Float).SetPrec(256).SetString(n.String())
+}
@@ -0,0 +1,103 @@
+package jsonschema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		err    string
+	}{
+		{"object", `{"type": "object", "properties": {"a": {"type": "string"}}, "required": ["a"]}`, ""},
+		{"annotations", `{"$schema": "https://json-schema.org/draft/2020-12/schema", "title": "t", "description": "d", "default": 1, "type": "integer"}`, ""},
+		{"not an object", `["string"]`, "must be a JSON object"},
+		{"unknown type", `{"type": "text"}`, `unknown type "text"`},
+		{"bad pattern", `{"type": "string", "pattern": "("}`, "invalid pattern"},
+		{"unknown keyword", `{"type": "string", "format": "email"}`, `#: unsupported keyword "format"`},
+		{"unknown nested keyword", `{"properties": {"a": {"type": "number", "exclusiveMinimum": 0}}}`, `#/properties/a: unsupported keyword "exclusiveMinimum"`},
+		{"unknown keyword in items", `{"items": {"$ref": "#/$defs/x"}}`, `#/items: unsupported keyword "$ref"`},
+		{"unknown keyword in anyOf", `{"anyOf": [{"type": "string"}, {"not": {}}]}`, `#/anyOf/1: unsupported keyword "not"`},
+		{"unknown keyword in additionalProperties", `{"additionalProperties": {"minProperties": 1}}`, `#/additionalProperties: unsupported keyword "minProperties"`},
+		{"closed object", `{"type": "object", "additionalProperties": false}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.schema))
+			switch {
+			case tt.err == "" && err != nil:
+				t.Fatalf("Parse: %v", err)
+			case tt.err != "" && err == nil:
+				t.Fatalf("Parse: no error, want %q", tt.err)
+			case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+				t.Fatalf("Parse: %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		doc    string
+		valid  bool
+	}{
+		{"integer", `{"type": "integer"}`, `1`, true},
+		{"integer with zero fraction", `{"type": "integer"}`, `1.0`, true},
+		{"integer in exponent form", `{"type": "integer"}`, `1e2`, true},
+		{"fraction is not integer", `{"type": "integer"}`, `1.5`, false},
+		{"integer is a number", `{"type": "number"}`, `3`, true},
+		{"string is not a number", `{"type": "number"}`, `"3"`, false},
+		{"type list", `{"type": ["string", "null"]}`, `null`, true},
+
+		{"enum", `{"enum": [1, "a"]}`, `"a"`, true},
+		{"enum number by value", `{"enum": [1]}`, `1.0`, true},
+		{"enum miss", `{"enum": [1, "a"]}`, `2`, false},
+		{"enum object", `{"enum": [{"a": [1, 2]}]}`, `{"a": [1.0, 2]}`, true},
+		{"enum object miss", `{"enum": [{"a": [1, 2]}]}`, `{"a": [2, 1]}`, false},
+		{"enum big integer", `{"enum": [12345678901234567890]}`, `12345678901234567891`, false},
+
+		{"const", `{"const": "x"}`, `"x"`, true},
+		{"const miss", `{"const": "x"}`, `"y"`, false},
+		{"const null", `{"const": null}`, `null`, true},
+		{"const null miss", `{"const": null}`, `0`, false},
+		{"const false", `{"const": false}`, `null`, false},
+
+		{"required", `{"type": "object", "required": ["a"]}`, `{"b": 1}`, false},
+		{"closed", `{"type": "object", "properties": {"a": {}}, "additionalProperties": false}`, `{"a": 1, "b": 2}`, false},
+		{"additional schema", `{"type": "object", "additionalProperties": {"type": "string"}}`, `{"a": 1}`, false},
+		{"items", `{"type": "array", "items": {"type": "integer"}, "maxItems": 2}`, `[1, 2.0]`, true},
+		{"too many items", `{"type": "array", "maxItems": 1}`, `[1, 2]`, false},
+		{"min length counts runes", `{"type": "string", "minLength": 3}`, `"абв"`, true},
+		{"max length", `{"type": "string", "maxLength": 2}`, `"abc"`, false},
+		{"pattern", `{"type": "string", "pattern": "^[a-z]+$"}`, `"abc1"`, false},
+		{"minimum", `{"type": "number", "minimum": 0}`, `-0.5`, false},
+		{"maximum", `{"type": "number", "maximum": 10}`, `10`, true},
+
+		{"anyOf", `{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, `2`, true},
+		{"anyOf miss", `{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, `true`, false},
+		{"oneOf both", `{"oneOf": [{"type": "number"}, {"type": "integer"}]}`, `2`, false},
+		{"oneOf one", `{"oneOf": [{"type": "number"}, {"type": "integer"}]}`, `2.5`, true},
+		{"allOf", `{"allOf": [{"minimum": 1}, {"maximum": 3}]}`, `4`, false},
+
+		{"not JSON", `{"type": "object"}`, `{"a":`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse([]byte(tt.schema))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			_, err = s.Validate([]byte(tt.doc))
+			if tt.valid && err != nil {
+				t.Fatalf("Validate(%s): %v", tt.doc, err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatalf("Validate(%s): no error", tt.doc)
+			}
+		})
+	}
+}
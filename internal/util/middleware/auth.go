@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"strings"
@@ -10,7 +11,7 @@ const UserIDContextKey = "user_id"
 
 // AuthMiddleware returns middleware that checks Authorization: Bearer <token>
 // The parseFn should return userID string and error
-func AuthMiddleware(parseFn func(token string) (string, error)) echo.MiddlewareFunc {
+func AuthMiddleware(parseFn func(ctx context.Context, token string) (string, error)) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			authHeader := c.Request().Header.Get("Authorization")
@@ -24,7 +25,7 @@ func AuthMiddleware(parseFn func(token string) (string, error)) echo.MiddlewareF
 			}
 
 			token := parts[1]
-			userID, err := parseFn(token)
+			userID, err := parseFn(c.Request().Context(), token)
 			if err != nil {
 				return c.JSON(http.StatusUnauthorized, echo.Map{"error": "invalid or expired token"})
 			}
@@ -44,3 +45,24 @@ func UserIDFromContext(ctx echo.Context) (string, bool) {
 	uid, ok := v.(string)
 	return uid, ok
 }
+
+// AdminMiddleware allows the request only for the listed user IDs.
+// It must run after AuthMiddleware.
+func AdminMiddleware(admins []string) echo.MiddlewareFunc {
+	allowed := make(map[string]struct{}, len(admins))
+	for _, id := range admins {
+		allowed[id] = struct{}{}
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			uid, ok := UserIDFromContext(c)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, echo.Map{"error": "user not found"})
+			}
+			if _, ok := allowed[uid]; !ok {
+				return c.JSON(http.StatusForbidden, echo.Map{"error": "admin access required"})
+			}
+			return next(c)
+		}
+	}
+}
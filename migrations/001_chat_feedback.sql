@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS chat_exchange (
+    message_id UUID PRIMARY KEY,
+    rquid      TEXT,
+    user_id    TEXT        NOT NULL,
+    question   TEXT        NOT NULL,
+    chunks     JSONB       NOT NULL DEFAULT '[]',
+    answer     TEXT        NOT NULL,
+    model      TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS chat_exchange_user_rquid_idx ON chat_exchange (user_id, rquid);
+
+CREATE TABLE IF NOT EXISTS chat_feedback (
+    feedback_id  UUID PRIMARY KEY,
+    message_id   UUID        NOT NULL REFERENCES chat_exchange (message_id) ON DELETE CASCADE,
+    user_id      TEXT        NOT NULL,
+    rating       SMALLINT    NOT NULL CHECK (rating IN (-1, 1)),
+    comment      TEXT,
+    wrong_source BOOLEAN     NOT NULL DEFAULT false,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (message_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS chat_feedback_negative_idx ON chat_feedback (created_at DESC)
+    WHERE rating < 0 OR wrong_source;
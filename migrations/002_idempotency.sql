@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS idempotency_key (
+    request_id   TEXT        NOT NULL,
+    user_id      TEXT        NOT NULL,
+    scope        TEXT        NOT NULL,
+    payload_hash TEXT        NOT NULL,
+    status_code  INT,
+    response     JSONB,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at   TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, scope, request_id)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_key_expires_idx ON idempotency_key (expires_at);
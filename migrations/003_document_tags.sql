@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS document (
+    document_id   TEXT PRIMARY KEY,
+    user_id       TEXT NOT NULL,
+    document_name TEXT NOT NULL
+);
+
+ALTER TABLE document
+    ADD COLUMN IF NOT EXISTS tags     TEXT[]  NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS priority BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS document_user_idx ON document (user_id);
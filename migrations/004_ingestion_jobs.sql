@@ -0,0 +1,20 @@
+ALTER TABLE document
+    ADD COLUMN IF NOT EXISTS status     TEXT        NOT NULL DEFAULT 'indexed',
+    ADD COLUMN IF NOT EXISTS progress   SMALLINT    NOT NULL DEFAULT 100,
+    ADD COLUMN IF NOT EXISTS error      TEXT,
+    ADD COLUMN IF NOT EXISTS report     JSONB,
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE TABLE IF NOT EXISTS ingestion_job (
+    document_id  TEXT        PRIMARY KEY,
+    user_id      TEXT        NOT NULL,
+    filename     TEXT        NOT NULL,
+    data         BYTEA       NOT NULL,
+    priority     BOOLEAN     NOT NULL DEFAULT false,
+    attempts     INT         NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS ingestion_job_ready_idx ON ingestion_job (priority DESC, created_at);
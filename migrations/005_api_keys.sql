@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS api_key (
+    key_id       TEXT        PRIMARY KEY,
+    user_id      TEXT        NOT NULL,
+    name         TEXT        NOT NULL,
+    prefix       TEXT        NOT NULL,
+    key_hash     TEXT        NOT NULL UNIQUE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS api_key_user_idx ON api_key (user_id, created_at);